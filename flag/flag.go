@@ -6,6 +6,7 @@ import (
 )
 
 type Flag struct {
+	Config  string
 	Service service.Service
 }
 
@@ -0,0 +1,5 @@
+package check
+
+type Check struct {
+	Enabled string
+}
@@ -1,7 +1,27 @@
 package collector
 
-import "github.com/giantswarm/github-exporter/flag/service/collector/issue"
+import (
+	"github.com/giantswarm/github-exporter/flag/service/collector/check"
+	"github.com/giantswarm/github-exporter/flag/service/collector/discovery"
+	"github.com/giantswarm/github-exporter/flag/service/collector/issue"
+	"github.com/giantswarm/github-exporter/flag/service/collector/milestone"
+	"github.com/giantswarm/github-exporter/flag/service/collector/pullrequest"
+	"github.com/giantswarm/github-exporter/flag/service/collector/release"
+	"github.com/giantswarm/github-exporter/flag/service/collector/repository"
+	"github.com/giantswarm/github-exporter/flag/service/collector/traffic"
+	"github.com/giantswarm/github-exporter/flag/service/collector/workflow"
+)
 
 type Collector struct {
-	Issue issue.Issue
+	Check        check.Check
+	Discovery    discovery.Discovery
+	Interval     string
+	Issue        issue.Issue
+	Milestone    milestone.Milestone
+	PullRequest  pullrequest.PullRequest
+	Release      release.Release
+	Repositories string
+	Repository   repository.Repository
+	Traffic      traffic.Traffic
+	Workflow     workflow.Workflow
 }
@@ -0,0 +1,11 @@
+package discovery
+
+type Discovery struct {
+	Exclude       string
+	Include       string
+	Interval      string
+	Organizations string
+	SkipArchived  string
+	SkipForks     string
+	SkipPrivate   string
+}
@@ -0,0 +1,8 @@
+package breakdown
+
+type Breakdown struct {
+	Limit     string
+	Selectors string
+	Teams     string
+	Users     string
+}
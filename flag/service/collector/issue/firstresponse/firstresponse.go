@@ -0,0 +1,7 @@
+package firstresponse
+
+type FirstResponse struct {
+	Budget  string
+	Enabled string
+	Window  string
+}
@@ -1,5 +1,16 @@
 package issue
 
+import (
+	"github.com/giantswarm/github-exporter/flag/service/collector/issue/breakdown"
+	"github.com/giantswarm/github-exporter/flag/service/collector/issue/firstresponse"
+)
+
 type Issue struct {
-	CustomLabels string
+	Breakdown       breakdown.Breakdown
+	CustomLabels    string
+	Dimensions      string
+	FirstResponse   firstresponse.FirstResponse
+	LifetimeBuckets string
+	Retention       string
+	StaleThresholds string
 }
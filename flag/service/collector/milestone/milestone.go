@@ -0,0 +1,6 @@
+package milestone
+
+type Milestone struct {
+	Enabled string
+	State   string
+}
@@ -0,0 +1,7 @@
+package pullrequest
+
+type PullRequest struct {
+	Buckets   string
+	Enabled   string
+	Retention string
+}
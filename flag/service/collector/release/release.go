@@ -0,0 +1,7 @@
+package release
+
+type Release struct {
+	AssetPattern   string
+	Enabled        string
+	RecentReleases string
+}
@@ -0,0 +1,5 @@
+package repository
+
+type Repository struct {
+	Enabled string
+}
@@ -0,0 +1,6 @@
+package traffic
+
+type Traffic struct {
+	Enabled string
+	TopN    string
+}
@@ -0,0 +1,7 @@
+package workflow
+
+type Workflow struct {
+	Buckets   string
+	Enabled   string
+	Retention string
+}
@@ -1,5 +1,10 @@
 package auth
 
+import (
+	"github.com/giantswarm/github-exporter/flag/service/github/auth/app"
+)
+
 type Auth struct {
+	App   app.App
 	Token string
 }
@@ -2,8 +2,16 @@ package github
 
 import (
 	"github.com/giantswarm/github-exporter/flag/service/github/auth"
+	"github.com/giantswarm/github-exporter/flag/service/github/cache"
+	"github.com/giantswarm/github-exporter/flag/service/github/ratelimit"
+	"github.com/giantswarm/github-exporter/flag/service/github/tls"
 )
 
 type Github struct {
-	Auth auth.Auth
+	Auth      auth.Auth
+	BaseURL   string
+	Cache     cache.Cache
+	RateLimit ratelimit.RateLimit
+	TLS       tls.TLS
+	UploadURL string
 }
@@ -0,0 +1,5 @@
+package ratelimit
+
+type RateLimit struct {
+	Threshold string
+}
@@ -3,9 +3,11 @@ package service
 import (
 	"github.com/giantswarm/github-exporter/flag/service/collector"
 	"github.com/giantswarm/github-exporter/flag/service/github"
+	"github.com/giantswarm/github-exporter/flag/service/store"
 )
 
 type Service struct {
 	Collector collector.Collector
 	Github    github.Github
+	Store     store.Store
 }
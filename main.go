@@ -3,14 +3,22 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"strings"
+	"time"
 
 	"github.com/giantswarm/github-exporter/flag"
 	"github.com/giantswarm/github-exporter/server"
 	"github.com/giantswarm/github-exporter/service"
+	"github.com/giantswarm/github-exporter/service/configfile"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/microkit/command"
+	daemonflag "github.com/giantswarm/microkit/command/daemon/flag"
+	microflag "github.com/giantswarm/microkit/flag"
 	microserver "github.com/giantswarm/microkit/server"
 	"github.com/giantswarm/micrologger"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
@@ -43,6 +51,10 @@ func mainError() error {
 		}
 	}
 
+	// daemonFlags are the flags of the daemon command. They are defined below
+	// and used to validate config files.
+	var daemonFlags *pflag.FlagSet
+
 	// We define a server factory to create the custom server once all command
 	// line flags are parsed and all microservice configuration is storted out.
 	newServerFactory := func(v *viper.Viper) microserver.Server {
@@ -54,6 +66,7 @@ func mainError() error {
 
 				Description: description,
 				Flag:        f,
+				FlagSet:     daemonFlags,
 				GitCommit:   gitCommit,
 				ProjectName: name,
 				Source:      source,
@@ -107,11 +120,197 @@ func mainError() error {
 	}
 
 	daemonCommand := newCommand.DaemonCommand().CobraCommand()
+	daemonFlags = daemonCommand.PersistentFlags()
+
+	err = registerDaemonFlags(daemonFlags)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	// The config file given by --config is loaded by the service, which
+	// validates it and reloads it on changes. So microkit must not look up
+	// config files on its own in this case. Otherwise a config file in its
+	// default directory would be applied a second time.
+	daemonRun := daemonCommand.Run
+	daemonCommand.Run = func(cmd *cobra.Command, args []string) {
+		err := setConfigLookup(cmd.Flags())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid configuration: %s\n", err)
+			os.Exit(1)
+		}
 
-	daemonCommand.PersistentFlags().String(f.Service.Collector.Issue.CustomLabels, "[]", "JSON list of custom labels.")
-	daemonCommand.PersistentFlags().String(f.Service.Github.Auth.Token, "", "Auth token to access the Github API.")
+		daemonRun(cmd, args)
+	}
+
+	// The validate-config command validates the settings the daemon command
+	// would be executed with, including the config file, without starting the
+	// daemon. It therefore shares the flags of the daemon command.
+	validateConfigCommand := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Validate the configuration of the daemon.",
+		Long:  "Validate the configuration of the daemon, e.g. validate-config --config=config.yaml.",
+		Run: func(cmd *cobra.Command, args []string) {
+			err := validateConfig(newLogger, cmd.Flags())
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "invalid configuration: %s\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Println("configuration is valid")
+		},
+	}
+	validateConfigCommand.Flags().AddFlagSet(daemonFlags)
+	newCommand.CobraCommand().AddCommand(validateConfigCommand)
 
 	newCommand.CobraCommand().Execute()
 
 	return nil
 }
+
+// registerDaemonFlags registers the flags of the daemon command, which are
+// shared by the validate-config command, in the given flag set.
+func registerDaemonFlags(fs *pflag.FlagSet) error {
+	fs.String(f.Config, "", "File path of a YAML or JSON config file structured like the flags, e.g. service.collector.interval. Flags take precedence over the config file.")
+	fs.Bool(f.Service.Collector.Check.Enabled, false, "Whether to collect the commit statuses and check suites of the default branch.")
+	fs.String(f.Service.Collector.Discovery.Exclude, "[]", "JSON list of glob patterns matching org/repo of discovered repositories which are not collected.")
+	fs.String(f.Service.Collector.Discovery.Include, "[]", "JSON list of glob patterns matching org/repo of discovered repositories which are collected. All discovered repositories are collected if empty.")
+	fs.Duration(f.Service.Collector.Discovery.Interval, 10*time.Minute, "Interval in which organizations are scanned for new repositories.")
+	fs.String(f.Service.Collector.Discovery.Organizations, "[]", "JSON list of organizations of which all repositories are collected.")
+	fs.Bool(f.Service.Collector.Discovery.SkipArchived, false, "Whether to skip archived repositories during discovery.")
+	fs.Bool(f.Service.Collector.Discovery.SkipForks, false, "Whether to skip forked repositories during discovery.")
+	fs.Bool(f.Service.Collector.Discovery.SkipPrivate, false, "Whether to skip private repositories during discovery.")
+	fs.Duration(f.Service.Collector.Interval, 5*time.Minute, "Interval in which the collected data is refreshed from the Github API.")
+	fs.Int(f.Service.Collector.Issue.Breakdown.Limit, 10, "Maximum number of assignees and authors exported individually per breakdown selector. Issues of other users are counted as _other.")
+	fs.String(f.Service.Collector.Issue.Breakdown.Selectors, "[]", "JSON list of label selectors for which open issues are counted per assignee and author, e.g. [ \"team/batman\" ].")
+	fs.String(f.Service.Collector.Issue.Breakdown.Teams, "[]", "JSON list of teams of the form org/team whose members are exported individually in the breakdown. Resolved using the Teams API.")
+	fs.String(f.Service.Collector.Issue.Breakdown.Users, "[]", "JSON list of logins of users exported individually in the breakdown. All users are exported up to the limit if neither users nor teams are given.")
+	fs.String(f.Service.Collector.Issue.CustomLabels, "[]", "JSON list of label selectors, e.g. postmortem,team/* or kind/bug AND NOT wontfix.")
+	fs.String(f.Service.Collector.Issue.Dimensions, "[]", "JSON list of label prefixes exported as dimensions of the issue count, e.g. [ \"team\", \"kind\" ] for labels like team/batman and kind/bug.")
+	fs.Int(f.Service.Collector.Issue.FirstResponse.Budget, 100, "Maximum number of Github API requests per refresh used to fetch comments and events of issues to determine their first response.")
+	fs.Bool(f.Service.Collector.Issue.FirstResponse.Enabled, false, "Whether to collect the time to the first response to recently created issues. Fetches the comments and events of every updated issue within the window.")
+	fs.Duration(f.Service.Collector.Issue.FirstResponse.Window, 30*24*time.Hour, "Time since creation within which issues are checked for their first response.")
+	fs.String(f.Service.Collector.Issue.LifetimeBuckets, "[]", "JSON list of durations used as buckets of the issue lifetime histogram, e.g. [ \"24h\", \"168h\" ]. Defaults to exponential buckets from one to 512 days.")
+	fs.Duration(f.Service.Collector.Issue.Retention, 365*24*time.Hour, "Time after which closed issues are not counted anymore. Also limits the initial sync to issues updated within this time. All issues are kept if 0.")
+	fs.String(f.Service.Collector.Issue.StaleThresholds, "[]", "JSON list of durations without activity after which open issues are counted as stale, e.g. [ \"720h\", \"2160h\" ]. Defaults to 30, 90 and 180 days.")
+	fs.Bool(f.Service.Collector.Milestone.Enabled, false, "Whether to collect milestone metrics.")
+	fs.String(f.Service.Collector.Milestone.State, "open", "State of the milestones to collect, either open, closed or all.")
+	fs.String(f.Service.Collector.PullRequest.Buckets, "[]", "JSON list of durations used as buckets of the pull request time to merge and time to first review histograms. Defaults to exponential buckets from one to 2048 hours.")
+	fs.Bool(f.Service.Collector.PullRequest.Enabled, false, "Whether to collect pull request metrics. Fetches the reviews of every updated pull request.")
+	fs.Duration(f.Service.Collector.PullRequest.Retention, 365*24*time.Hour, "Time after which closed and merged pull requests are not counted anymore. Also limits the initial sync to pull requests updated within this time. All pull requests are kept if 0.")
+	fs.String(f.Service.Collector.Release.AssetPattern, "", "Regular expression matching the names of the release assets whose downloads are collected. All assets are collected if empty.")
+	fs.Bool(f.Service.Collector.Release.Enabled, false, "Whether to collect release metrics.")
+	fs.Int(f.Service.Collector.Release.RecentReleases, 5, "Number of the most recently published releases whose asset downloads are collected.")
+	fs.String(f.Service.Collector.Repositories, "[\"giantswarm/giantswarm\"]", "JSON list of repositories to collect metrics for, each of the form org/repo. Set to [] to only collect discovered repositories.")
+	fs.Bool(f.Service.Collector.Repository.Enabled, false, "Whether to collect repository statistics like stars, forks and watchers.")
+	fs.Bool(f.Service.Collector.Traffic.Enabled, false, "Whether to collect repository traffic metrics. Requires push access to the repositories.")
+	fs.Int(f.Service.Collector.Traffic.TopN, 10, "Maximum number of referrers and paths collected per repository.")
+	fs.String(f.Service.Collector.Workflow.Buckets, "[]", "JSON list of durations used as buckets of the workflow run and queue duration histograms. Defaults to exponential buckets from 15 seconds to 256 minutes.")
+	fs.Bool(f.Service.Collector.Workflow.Enabled, false, "Whether to collect Github Actions workflow run metrics.")
+	fs.Duration(f.Service.Collector.Workflow.Retention, 7*24*time.Hour, "Time after which workflow runs are not counted anymore. Also limits the initial sync to workflow runs created within this time.")
+	fs.Int64(f.Service.Github.Auth.App.ID, 0, "ID of the Github App used to access the Github API instead of an auth token.")
+	fs.Int64(f.Service.Github.Auth.App.InstallationID, 0, "ID of the Github App installation used to access the Github API.")
+	fs.String(f.Service.Github.Auth.App.PrivateKeyFile, "", "File path of the Github App's PEM encoded private key.")
+	fs.String(f.Service.Github.Auth.Token, "", "Auth token to access the Github API.")
+	fs.String(f.Service.Github.BaseURL, "", "Base URL of the Github API, e.g. https://github.example.com/api/v3/ for Github Enterprise Server. Defaults to the public Github API.")
+	fs.Int(f.Service.Github.Cache.MaxBytes, 64*1024*1024, "Maximum total size in bytes of the bodies of the Github API responses cached to send conditional requests. Caching is disabled if 0.")
+	fs.Int(f.Service.Github.RateLimit.Threshold, 100, "Number of remaining Github API requests below which requests are paused until the rate limit resets. Ignored if not below the rate limit.")
+	fs.String(f.Service.Github.TLS.CaFile, "", "File path of the CA bundle used to verify the Github API's certificate, if any.")
+	fs.String(f.Service.Github.TLS.CrtFile, "", "File path of the TLS client certificate file used to access the Github API, if any.")
+	fs.Bool(f.Service.Github.TLS.InsecureSkipVerify, false, "Whether to skip verifying the Github API's certificate. Do not use in production.")
+	fs.String(f.Service.Github.TLS.KeyFile, "", "File path of the TLS client key file used to access the Github API, if any.")
+	fs.String(f.Service.Github.UploadURL, "", "Upload URL of the Github API, e.g. https://github.example.com/api/uploads/ for Github Enterprise Server. Defaults to the base URL.")
+	fs.String(f.Service.Store.Directory, "", "Directory the state of the collectors is persisted to, so that restarts do not cause a full sync. The state is kept in memory only if empty.")
+
+	// Flags holding JSON lists are configured using plain lists in config
+	// files.
+	err := configfile.MarkLists(
+		fs,
+		f.Service.Collector.Discovery.Exclude,
+		f.Service.Collector.Discovery.Include,
+		f.Service.Collector.Discovery.Organizations,
+		f.Service.Collector.Issue.Breakdown.Selectors,
+		f.Service.Collector.Issue.Breakdown.Teams,
+		f.Service.Collector.Issue.Breakdown.Users,
+		f.Service.Collector.Issue.CustomLabels,
+		f.Service.Collector.Issue.Dimensions,
+		f.Service.Collector.Issue.LifetimeBuckets,
+		f.Service.Collector.Issue.StaleThresholds,
+		f.Service.Collector.PullRequest.Buckets,
+		f.Service.Collector.Repositories,
+		f.Service.Collector.Workflow.Buckets,
+	)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// setConfigLookup sets the directories and files in which microkit looks up
+// config files. No directories are looked up if --config is given. Both flags
+// are always set explicitly, because viper hides flags nested below other
+// flags, like config.dirs below config, unless they were changed. Environment
+// variables are applied by microkit either way.
+func setConfigLookup(fs *pflag.FlagSet) error {
+	lookup := daemonflag.New().Config
+
+	dirs, err := fs.GetStringSlice(lookup.Dirs)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	files, err := fs.GetStringSlice(lookup.Files)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	if fs.Lookup(f.Config).Value.String() != "" {
+		if fs.Changed(lookup.Dirs) {
+			return microerror.Maskf(invalidFlagError, "--%s must not be combined with --%s", lookup.Dirs, f.Config)
+		}
+
+		dirs = nil
+	}
+
+	// Setting changed slice flags appends to their values, so only flags which
+	// were not changed are set.
+	if !fs.Changed(lookup.Dirs) {
+		err = fs.Set(lookup.Dirs, strings.Join(dirs, ","))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+	if !fs.Changed(lookup.Files) {
+		err = fs.Set(lookup.Files, strings.Join(files, ","))
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// validateConfig validates the settings given by the given flags, including
+// the config file, by creating the service without booting it.
+func validateConfig(logger micrologger.Logger, fs *pflag.FlagSet) error {
+	v := viper.New()
+	microflag.Parse(v, fs)
+
+	c := service.Config{
+		Logger: logger,
+
+		Description: description,
+		Flag:        f,
+		FlagSet:     fs,
+		GitCommit:   gitCommit,
+		ProjectName: name,
+		Source:      source,
+		Viper:       v,
+	}
+
+	_, err := service.New(c)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	daemonflag "github.com/giantswarm/microkit/command/daemon/flag"
+	microflag "github.com/giantswarm/microkit/flag"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+func Test_setConfigLookup(t *testing.T) {
+	lookup := daemonflag.New().Config
+
+	testCases := []struct {
+		name          string
+		args          []string
+		expectedDirs  []string
+		expectedFiles []string
+		expectedError bool
+	}{
+		{
+			name:          "case 0 microkit looks up its default config files without --config",
+			args:          nil,
+			expectedDirs:  []string{"."},
+			expectedFiles: []string{"config"},
+		},
+		{
+			name:          "case 1 microkit does not look up config files with --config",
+			args:          []string{"--config=/etc/github-exporter/config.yaml"},
+			expectedDirs:  nil,
+			expectedFiles: []string{"config"},
+		},
+		{
+			name:          "case 2 explicitly given lookup settings are kept without --config",
+			args:          []string{"--config.dirs=/etc/github-exporter", "--config.files=exporter"},
+			expectedDirs:  []string{"/etc/github-exporter"},
+			expectedFiles: []string{"exporter"},
+		},
+		{
+			name:          "case 3 --config cannot be combined with config directories",
+			args:          []string{"--config=config.yaml", "--config.dirs=/etc/github-exporter"},
+			expectedError: true,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.StringSlice(lookup.Dirs, []string{"."}, "")
+			fs.StringSlice(lookup.Files, []string{"config"}, "")
+			err := registerDaemonFlags(fs)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			err = fs.Parse(tc.args)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			err = setConfigLookup(fs)
+			if tc.expectedError {
+				if err == nil {
+					t.Fatalf("error == nil, want non-nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			// The lookup settings are read from viper just like microkit does.
+			v := viper.New()
+			microflag.Parse(v, fs)
+
+			dirs := v.GetStringSlice(lookup.Dirs)
+			if len(dirs) != 0 || len(tc.expectedDirs) != 0 {
+				if !cmp.Equal(dirs, tc.expectedDirs) {
+					t.Fatalf("\n\n%s\n", cmp.Diff(dirs, tc.expectedDirs))
+				}
+			}
+			files := v.GetStringSlice(lookup.Files)
+			if !cmp.Equal(files, tc.expectedFiles) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(files, tc.expectedFiles))
+			}
+		})
+	}
+}
+
+// Test_validateConfig_readme ensures that the config file example of the
+// README is valid.
+func Test_validateConfig_readme(t *testing.T) {
+	readme, err := ioutil.ReadFile("README.md")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	var example string
+	{
+		split := strings.SplitN(string(readme), "```yaml\n", 2)
+		if len(split) != 2 {
+			t.Fatalf("expected README to contain a YAML example")
+		}
+		example = strings.SplitN(split[1], "```", 2)[0]
+	}
+
+	dir, err := ioutil.TempDir("", "github-exporter-config")
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	err = ioutil.WriteFile(path, []byte(example), 0644)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	err = registerDaemonFlags(fs)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	err = fs.Parse([]string{"--" + f.Config + "=" + path})
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	err = validateConfig(logger, fs)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+}
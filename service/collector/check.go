@@ -0,0 +1,335 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/github-exporter/service/store"
+)
+
+var (
+	checkStatusDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemDefaultBranch, "status"),
+		"Combined status of the commit statuses and check suites of the HEAD commit of the default branch. The current state is 1, all others are 0.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelState,
+		},
+		nil,
+	)
+	checkFailingSuitesDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemDefaultBranch, "failing_check_suites_count"),
+		"Failing check suites of the HEAD commit of the default branch per Github App.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelApp,
+		},
+		nil,
+	)
+	checkFailingDurationDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemDefaultBranch, "failing_duration_seconds"),
+		"Time since the first failing HEAD commit of the default branch was committed, as of the last refresh. Pending commits do not reset it. Zero if the default branch is not failing.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+)
+
+const (
+	checkStateFailure = "failure"
+	checkStatePending = "pending"
+	checkStateSuccess = "success"
+)
+
+var (
+	// checkStates are all states of the default branch status. All of them are
+	// exported, so that state changes do not leave stale series behind.
+	checkStates = []string{
+		checkStateFailure,
+		checkStatePending,
+		checkStateSuccess,
+	}
+	// checkFailingConclusions are the conclusions of completed check suites
+	// which make the default branch fail.
+	checkFailingConclusions = map[string]bool{
+		"failure":   true,
+		"timed_out": true,
+	}
+)
+
+type CheckConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+	// Store persists since when the default branch of each repository is
+	// failing, so that restarts do not reset the failing duration.
+	Store store.Interface
+}
+
+type Check struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+	store        store.Interface
+
+	snapshot *snapshot
+}
+
+// checkSuite extends github.CheckSuite by the number of its latest check runs,
+// which is not supported by the vendored go-github version.
+type checkSuite struct {
+	github.CheckSuite
+
+	LatestCheckRunsCount *int `json:"latest_check_runs_count,omitempty"`
+}
+
+type checkSuiteList struct {
+	CheckSuites []*checkSuite `json:"check_suites"`
+}
+
+// checkFailure records since when the default branch of a repository is
+// failing. FailingSince is zero if it is not failing.
+type checkFailure struct {
+	FailingSince time.Time
+}
+
+func NewCheck(config CheckConfig) (*Check, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	c := &Check{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+		store:        config.Store,
+
+		snapshot: newSnapshot(),
+	}
+
+	return c, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (c *Check) Collect(ch chan<- prometheus.Metric) error {
+	c.snapshot.Collect(ch)
+	return nil
+}
+
+func (c *Check) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- checkStatusDesc
+	ch <- checkFailingSuitesDesc
+	ch <- checkFailingDurationDesc
+	return nil
+}
+
+// Refresh fetches the commit statuses and check suites of the HEAD commit of
+// the default branch of all discovered repositories and replaces the snapshot
+// emitted by Collect.
+func (c *Check) Refresh(ctx context.Context) error {
+	err := c.snapshot.Refresh(ctx, c.logger, c.discovery.Repositories(), c.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Check) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	repository, _, err := c.githubClient.Repositories.Get(ctx, r.Org, r.Name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	branch, _, err := c.githubClient.Repositories.GetBranch(ctx, r.Org, r.Name, repository.GetDefaultBranch())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	head := branch.GetCommit()
+
+	// The statuses and check suites are requested for the SHA of the HEAD
+	// commit instead of the branch, so that both belong to the same commit.
+	status, _, err := c.githubClient.Repositories.GetCombinedStatus(ctx, r.Org, r.Name, head.GetSHA(), &github.ListOptions{PerPage: 100})
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var suites []*checkSuite
+	{
+		page := 1
+		for {
+			req, err := c.githubClient.NewRequest("GET", fmt.Sprintf("repos/%s/%s/commits/%s/check-suites?page=%d&per_page=100", r.Org, r.Name, head.GetSHA(), page), nil)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			req.Header.Set("Accept", "application/vnd.github.antiope-preview+json")
+
+			var list checkSuiteList
+			res, err := c.githubClient.Do(ctx, req, &list)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+			suites = append(suites, list.CheckSuites...)
+
+			if res.NextPage == 0 {
+				break
+			}
+			page = res.NextPage
+		}
+	}
+
+	state, failingSuites := checkState(status, suites)
+
+	var failure checkFailure
+	{
+		_, err := c.store.Get(checkStoreKey(r), &failure)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		failure.FailingSince = failingSince(failure.FailingSince, state, head.GetCommit().GetCommitter().GetDate())
+
+		err = c.store.Put(checkStoreKey(r), failure)
+		if err != nil {
+			c.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed storing default branch failure of repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	for _, s := range checkStates {
+		var v float64
+		if s == state {
+			v = 1
+		}
+
+		m := prometheus.MustNewConstMetric(
+			checkStatusDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			s,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for app, v := range failingSuites {
+		m := prometheus.MustNewConstMetric(
+			checkFailingSuitesDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			app,
+		)
+		metrics = append(metrics, m)
+	}
+
+	{
+		var v float64
+		if !failure.FailingSince.IsZero() {
+			v = now.Sub(failure.FailingSince).Seconds()
+		}
+
+		m := prometheus.MustNewConstMetric(
+			checkFailingDurationDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	return metrics, nil
+}
+
+func checkStoreKey(r Repository) string {
+	return "check/" + r.String()
+}
+
+// failingSince returns since when the default branch is failing, given since
+// when it was failing as of the previous refresh, its current state and the
+// commit date of its HEAD commit. The default branch is failing since the
+// first failing HEAD commit was committed. Subsequent failing commits do not
+// reset it, and neither do pending commits, e.g. while the fix of a failure is
+// still being checked. Only a successful HEAD commit resets it.
+func failingSince(previous time.Time, state string, committedAt time.Time) time.Time {
+	switch state {
+	case checkStateFailure:
+		if previous.IsZero() {
+			return committedAt
+		}
+		return previous
+	case checkStateSuccess:
+		return time.Time{}
+	default:
+		return previous
+	}
+}
+
+// checkState combines the given combined commit status and check suites of a
+// commit into a single state and returns the number of failing check suites
+// per app. Failures take precedence over pending statuses and check suites.
+// Github combines failing and erroring commit statuses into failure. The
+// combined commit status is pending if there are no statuses at all, so it is
+// ignored in this case. Check suites without check runs are ignored, too.
+// Github creates check suites for every app subscribed to check suite events,
+// and the suites of apps which never create check runs stay queued forever.
+func checkState(status *github.CombinedStatus, suites []*checkSuite) (string, map[string]float64) {
+	failingSuites := map[string]float64{}
+	pending := false
+
+	for _, s := range suites {
+		if s.LatestCheckRunsCount != nil && *s.LatestCheckRunsCount == 0 {
+			continue
+		}
+		if s.GetStatus() != "completed" {
+			pending = true
+			continue
+		}
+		if checkFailingConclusions[s.GetConclusion()] {
+			failingSuites[s.GetApp().GetName()]++
+		}
+	}
+
+	if status.GetTotalCount() != 0 {
+		switch status.GetState() {
+		case checkStateFailure:
+			return checkStateFailure, failingSuites
+		case checkStatePending:
+			pending = true
+		}
+	}
+
+	if len(failingSuites) != 0 {
+		return checkStateFailure, failingSuites
+	}
+	if pending {
+		return checkStatePending, failingSuites
+	}
+
+	return checkStateSuccess, failingSuites
+}
@@ -0,0 +1,212 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func Test_Collector_Check_checkState(t *testing.T) {
+	suite := func(app string, status string, conclusion string) *checkSuite {
+		s := &checkSuite{
+			CheckSuite: github.CheckSuite{
+				App:    &github.App{Name: github.String(app)},
+				Status: github.String(status),
+			},
+			LatestCheckRunsCount: github.Int(1),
+		}
+		if conclusion != "" {
+			s.Conclusion = github.String(conclusion)
+		}
+
+		return s
+	}
+
+	testCases := []struct {
+		name                  string
+		status                *github.CombinedStatus
+		suites                []*checkSuite
+		expectedState         string
+		expectedFailingSuites map[string]float64
+	}{
+		{
+			name: "case 0 commit without statuses and check suites",
+			status: &github.CombinedStatus{
+				State:      github.String("pending"),
+				TotalCount: github.Int(0),
+			},
+			expectedState:         "success",
+			expectedFailingSuites: map[string]float64{},
+		},
+		{
+			name: "case 1 commit with successful statuses and check suites",
+			status: &github.CombinedStatus{
+				State:      github.String("success"),
+				TotalCount: github.Int(2),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "completed", "success"),
+				suite("CircleCI", "completed", "neutral"),
+			},
+			expectedState:         "success",
+			expectedFailingSuites: map[string]float64{},
+		},
+		{
+			name: "case 2 commit with pending statuses",
+			status: &github.CombinedStatus{
+				State:      github.String("pending"),
+				TotalCount: github.Int(1),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "completed", "success"),
+			},
+			expectedState:         "pending",
+			expectedFailingSuites: map[string]float64{},
+		},
+		{
+			name: "case 3 commit with check suites in progress",
+			status: &github.CombinedStatus{
+				State:      github.String("pending"),
+				TotalCount: github.Int(0),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "in_progress", ""),
+			},
+			expectedState:         "pending",
+			expectedFailingSuites: map[string]float64{},
+		},
+		{
+			name: "case 4 commit with failing check suites while others are pending",
+			status: &github.CombinedStatus{
+				State:      github.String("pending"),
+				TotalCount: github.Int(1),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "completed", "failure"),
+				suite("GitHub Actions", "completed", "timed_out"),
+				suite("CircleCI", "queued", ""),
+			},
+			expectedState: "failure",
+			expectedFailingSuites: map[string]float64{
+				"GitHub Actions": 2,
+			},
+		},
+		{
+			name: "case 5 commit with failing statuses",
+			status: &github.CombinedStatus{
+				State:      github.String("failure"),
+				TotalCount: github.Int(3),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "completed", "success"),
+			},
+			expectedState:         "failure",
+			expectedFailingSuites: map[string]float64{},
+		},
+		{
+			name: "case 6 commit with stale queued check suites without check runs",
+			status: &github.CombinedStatus{
+				State:      github.String("pending"),
+				TotalCount: github.Int(0),
+			},
+			suites: []*checkSuite{
+				suite("GitHub Actions", "completed", "success"),
+				{
+					CheckSuite: github.CheckSuite{
+						App:    &github.App{Name: github.String("Dependabot")},
+						Status: github.String("queued"),
+					},
+					LatestCheckRunsCount: github.Int(0),
+				},
+			},
+			expectedState:         "success",
+			expectedFailingSuites: map[string]float64{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			state, failingSuites := checkState(tc.status, tc.suites)
+
+			if state != tc.expectedState {
+				t.Fatalf("\n\n%s\n", cmp.Diff(state, tc.expectedState))
+			}
+			if !cmp.Equal(failingSuites, tc.expectedFailingSuites) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(failingSuites, tc.expectedFailingSuites))
+			}
+		})
+	}
+}
+
+func Test_Collector_Check_failingSince(t *testing.T) {
+	first := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	type refresh struct {
+		state       string
+		committedAt time.Time
+	}
+
+	testCases := []struct {
+		name           string
+		refreshes      []refresh
+		expectedResult time.Time
+	}{
+		{
+			name: "case 0 successful branch is not failing",
+			refreshes: []refresh{
+				{state: "success", committedAt: first},
+			},
+			expectedResult: time.Time{},
+		},
+		{
+			name: "case 1 failing branch is failing since the commit date",
+			refreshes: []refresh{
+				{state: "success", committedAt: first.Add(-time.Hour)},
+				{state: "failure", committedAt: first},
+			},
+			expectedResult: first,
+		},
+		{
+			name: "case 2 pending and failing commits after a failure do not reset it",
+			refreshes: []refresh{
+				{state: "failure", committedAt: first},
+				{state: "pending", committedAt: first.Add(time.Hour)},
+				{state: "failure", committedAt: first.Add(time.Hour)},
+				{state: "pending", committedAt: first.Add(2 * time.Hour)},
+			},
+			expectedResult: first,
+		},
+		{
+			name: "case 3 successful commit after a failure resets it",
+			refreshes: []refresh{
+				{state: "failure", committedAt: first},
+				{state: "pending", committedAt: first.Add(time.Hour)},
+				{state: "success", committedAt: first.Add(time.Hour)},
+			},
+			expectedResult: time.Time{},
+		},
+		{
+			name: "case 4 pending branch which was not failing is not failing",
+			refreshes: []refresh{
+				{state: "pending", committedAt: first},
+			},
+			expectedResult: time.Time{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var result time.Time
+			for _, r := range tc.refreshes {
+				result = failingSince(result, r.state, r.committedAt)
+			}
+
+			if !result.Equal(tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -2,17 +2,39 @@ package collector
 
 const (
 	namespace = "github_exporter"
-	subsystem = "issue"
-)
 
-const (
-	labelLabels = "labels"
-	labelOrg    = "org"
-	labelRepo   = "repo"
-	labelState  = "state"
+	subsystemDefaultBranch = "default_branch"
+	subsystemIssue         = "issue"
+	subsystemMilestone     = "milestone"
+	subsystemPullRequest   = "pull_request"
+	subsystemRelease       = "release"
+	subsystemRepository    = "repository"
+	subsystemTraffic       = "traffic"
+	subsystemWorkflow      = "workflow"
 )
 
 const (
-	githubOrg  = "giantswarm"
-	githubRepo = "giantswarm"
+	labelApp           = "app"
+	labelAsset         = "asset"
+	labelAssignee      = "assignee"
+	labelAuthor        = "author"
+	labelBranch        = "branch"
+	labelConclusion    = "conclusion"
+	labelDays          = "days"
+	labelDefaultBranch = "default_branch"
+	labelEvent         = "event"
+	labelLabels        = "labels"
+	labelLanguage      = "language"
+	labelLicense       = "license"
+	labelMilestone     = "milestone"
+	labelOrg           = "org"
+	labelPath          = "path"
+	labelReferrer      = "referrer"
+	labelRelease       = "release"
+	labelRepo          = "repo"
+	labelState         = "state"
+	labelStatus        = "status"
+	labelTopics        = "topics"
+	labelType          = "type"
+	labelWorkflow      = "workflow"
 )
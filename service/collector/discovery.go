@@ -0,0 +1,263 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+)
+
+type DiscoveryConfig struct {
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+
+	// Exclude is a list of glob patterns matched against the org/repo form of
+	// discovered repositories. Matching repositories are not collected.
+	Exclude []string
+	// Include is a list of glob patterns matched against the org/repo form of
+	// discovered repositories. If given, only matching repositories are
+	// collected.
+	Include []string
+	// Interval is the interval in which organizations are scanned for new
+	// repositories.
+	Interval time.Duration
+	// Organizations is the list of organizations of which all repositories are
+	// collected.
+	Organizations []string
+	// Repositories is the static list of repositories which are always
+	// collected, regardless of the discovery filters.
+	Repositories []Repository
+	SkipArchived bool
+	SkipForks    bool
+	SkipPrivate  bool
+}
+
+// Discovery provides the list of repositories the collectors have to collect
+// metrics for. This is the union of the statically configured repositories and
+// the repositories found in the configured organizations.
+type Discovery struct {
+	githubClient *github.Client
+	logger       micrologger.Logger
+
+	bootOnce   sync.Once
+	discovered []Repository
+	mutex      sync.RWMutex
+
+	exclude       []string
+	include       []string
+	interval      time.Duration
+	organizations []string
+	repositories  []Repository
+	skipArchived  bool
+	skipForks     bool
+	skipPrivate   bool
+}
+
+func NewDiscovery(config DiscoveryConfig) (*Discovery, error) {
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if len(config.Organizations) == 0 && len(config.Repositories) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Organizations or %T.Repositories must not be empty", config, config)
+	}
+	if len(config.Organizations) != 0 && config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be greater than 0", config)
+	}
+	for _, p := range append(config.Exclude, config.Include...) {
+		_, err := path.Match(p, "")
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "pattern %#q is malformed: %s", p, err)
+		}
+	}
+
+	d := &Discovery{
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+
+		bootOnce:   sync.Once{},
+		discovered: nil,
+		mutex:      sync.RWMutex{},
+
+		exclude:       config.Exclude,
+		include:       config.Include,
+		interval:      config.Interval,
+		organizations: config.Organizations,
+		repositories:  config.Repositories,
+		skipArchived:  config.SkipArchived,
+		skipForks:     config.SkipForks,
+		skipPrivate:   config.SkipPrivate,
+	}
+
+	return d, nil
+}
+
+// Boot discovers the repositories of the configured organizations once and
+// keeps refreshing them in the background until the given context is done.
+func (d *Discovery) Boot(ctx context.Context) {
+	d.bootOnce.Do(func() {
+		if len(d.organizations) == 0 {
+			return
+		}
+
+		d.refresh(ctx)
+
+		go func() {
+			ticker := time.NewTicker(d.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					d.refresh(ctx)
+				}
+			}
+		}()
+	})
+}
+
+// Repositories returns the sorted list of all repositories currently known to
+// the discovery.
+func (d *Discovery) Repositories() []Repository {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	seen := map[Repository]bool{}
+	var repositories []Repository
+
+	for _, r := range append(append([]Repository{}, d.repositories...), d.discovered...) {
+		if seen[r] {
+			continue
+		}
+		seen[r] = true
+
+		repositories = append(repositories, r)
+	}
+
+	sort.Slice(repositories, func(i, j int) bool {
+		return repositories[i].String() < repositories[j].String()
+	})
+
+	return repositories
+}
+
+func (d *Discovery) refresh(ctx context.Context) {
+	d.logger.LogCtx(ctx, "level", "debug", "message", "discovering repositories")
+
+	var discovered []Repository
+
+	for _, o := range d.organizations {
+		repositories, err := d.listOrganization(ctx, o)
+		if err != nil {
+			// In case a single organization cannot be listed we keep the
+			// repositories discovered previously, so that temporary API failures do
+			// not cause metrics to disappear.
+			d.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed discovering repositories of organization %#q", o), "stack", fmt.Sprintf("%#v", err))
+			repositories = d.discoveredOf(o)
+		}
+
+		discovered = append(discovered, repositories...)
+	}
+
+	d.mutex.Lock()
+	d.discovered = discovered
+	d.mutex.Unlock()
+
+	d.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("discovered %d repositories", len(discovered)))
+}
+
+func (d *Discovery) discoveredOf(org string) []Repository {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	var repositories []Repository
+	for _, r := range d.discovered {
+		if r.Org == org {
+			repositories = append(repositories, r)
+		}
+	}
+
+	return repositories
+}
+
+func (d *Discovery) listOrganization(ctx context.Context, org string) ([]Repository, error) {
+	opts := &github.RepositoryListByOrgOptions{
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+		Type: "all",
+	}
+
+	var repositories []Repository
+
+	for {
+		list, res, err := d.githubClient.Repositories.ListByOrg(ctx, org, opts)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		for _, repo := range list {
+			r := Repository{
+				Org:  repo.GetOwner().GetLogin(),
+				Name: repo.GetName(),
+			}
+
+			if !d.isWanted(repo, r) {
+				continue
+			}
+
+			repositories = append(repositories, r)
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return repositories, nil
+}
+
+func (d *Discovery) isWanted(repo *github.Repository, r Repository) bool {
+	if d.skipArchived && repo.GetArchived() {
+		return false
+	}
+	if d.skipForks && repo.GetFork() {
+		return false
+	}
+	if d.skipPrivate && repo.GetPrivate() {
+		return false
+	}
+
+	if len(d.include) != 0 && !matchesAny(d.include, r.String()) {
+		return false
+	}
+	if matchesAny(d.exclude, r.String()) {
+		return false
+	}
+
+	return true
+}
+
+func matchesAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		ok, _ := path.Match(p, s)
+		if ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func Test_Collector_Discovery_isWanted(t *testing.T) {
+	testCases := []struct {
+		name           string
+		discovery      *Discovery
+		repo           *github.Repository
+		expectedResult bool
+	}{
+		{
+			name:           "case 0 repository is wanted without filters",
+			discovery:      &Discovery{},
+			repo:           &github.Repository{},
+			expectedResult: true,
+		},
+		{
+			name: "case 1 archived repository is skipped",
+			discovery: &Discovery{
+				skipArchived: true,
+			},
+			repo: &github.Repository{
+				Archived: github.Bool(true),
+			},
+			expectedResult: false,
+		},
+		{
+			name: "case 2 forked repository is skipped",
+			discovery: &Discovery{
+				skipForks: true,
+			},
+			repo: &github.Repository{
+				Fork: github.Bool(true),
+			},
+			expectedResult: false,
+		},
+		{
+			name: "case 3 private repository is skipped",
+			discovery: &Discovery{
+				skipPrivate: true,
+			},
+			repo: &github.Repository{
+				Private: github.Bool(true),
+			},
+			expectedResult: false,
+		},
+		{
+			name: "case 4 repository does not match include patterns",
+			discovery: &Discovery{
+				include: []string{"giantswarm/*-operator"},
+			},
+			repo:           &github.Repository{},
+			expectedResult: false,
+		},
+		{
+			name: "case 5 repository does match include patterns",
+			discovery: &Discovery{
+				include: []string{"giantswarm/*-exporter"},
+			},
+			repo:           &github.Repository{},
+			expectedResult: true,
+		},
+		{
+			name: "case 6 repository does match exclude patterns",
+			discovery: &Discovery{
+				include: []string{"giantswarm/*"},
+				exclude: []string{"*/github-*"},
+			},
+			repo:           &github.Repository{},
+			expectedResult: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			r := Repository{
+				Org:  "giantswarm",
+				Name: "github-exporter",
+			}
+
+			result := tc.discovery.isWanted(tc.repo, r)
+
+			if result != tc.expectedResult {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
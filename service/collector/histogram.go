@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"sort"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// histogram accumulates observations so that they can be emitted as const
+// histogram. Other than prometheus.Histogram it is built from scratch on every
+// refresh and thus only ever reflects the data of the current snapshot.
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	count   uint64
+	sum     float64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	h := &histogram{
+		buckets: buckets,
+		counts:  make([]uint64, len(buckets)),
+	}
+
+	return h
+}
+
+func (h *histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+	if i < len(h.buckets) {
+		h.counts[i]++
+	}
+
+	h.count++
+	h.sum += v
+}
+
+func (h *histogram) Metric(desc *prometheus.Desc, labelValues ...string) prometheus.Metric {
+	buckets := map[float64]uint64{}
+
+	var cumulative uint64
+	for i, b := range h.buckets {
+		cumulative += h.counts[i]
+		buckets[b] = cumulative
+	}
+
+	return prometheus.MustNewConstHistogram(desc, h.count, h.sum, buckets, labelValues...)
+}
@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_Collector_histogram(t *testing.T) {
+	testCases := []struct {
+		name            string
+		buckets         []float64
+		observations    []float64
+		expectedCount   uint64
+		expectedSum     float64
+		expectedBuckets map[float64]uint64
+	}{
+		{
+			name:            "case 0 no observations",
+			buckets:         []float64{1, 2, 4},
+			observations:    nil,
+			expectedCount:   0,
+			expectedSum:     0,
+			expectedBuckets: map[float64]uint64{1: 0, 2: 0, 4: 0},
+		},
+		{
+			name:            "case 1 observations are counted cumulatively",
+			buckets:         []float64{1, 2, 4},
+			observations:    []float64{0.5, 1, 3, 3},
+			expectedCount:   4,
+			expectedSum:     7.5,
+			expectedBuckets: map[float64]uint64{1: 2, 2: 2, 4: 4},
+		},
+		{
+			name:            "case 2 observations above the highest bucket are only counted in total",
+			buckets:         []float64{1, 2, 4},
+			observations:    []float64{2, 8},
+			expectedCount:   2,
+			expectedSum:     10,
+			expectedBuckets: map[float64]uint64{1: 0, 2: 1, 4: 1},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			h := newHistogram(tc.buckets)
+			for _, o := range tc.observations {
+				h.Observe(o)
+			}
+
+			desc := prometheus.NewDesc("test", "test", nil, nil)
+
+			var m dto.Metric
+			err := h.Metric(desc).Write(&m)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			if m.GetHistogram().GetSampleCount() != tc.expectedCount {
+				t.Fatalf("\n\n%s\n", cmp.Diff(m.GetHistogram().GetSampleCount(), tc.expectedCount))
+			}
+			if m.GetHistogram().GetSampleSum() != tc.expectedSum {
+				t.Fatalf("\n\n%s\n", cmp.Diff(m.GetHistogram().GetSampleSum(), tc.expectedSum))
+			}
+
+			buckets := map[float64]uint64{}
+			for _, b := range m.GetHistogram().GetBucket() {
+				buckets[b.GetUpperBound()] = b.GetCumulativeCount()
+			}
+			if !cmp.Equal(buckets, tc.expectedBuckets) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(buckets, tc.expectedBuckets))
+			}
+		})
+	}
+}
@@ -3,6 +3,9 @@ package collector
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -10,11 +13,13 @@ import (
 	"github.com/giantswarm/micrologger"
 	"github.com/google/go-github/github"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/github-exporter/service/store"
 )
 
 var (
 	issueLabelsDesc *prometheus.Desc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, subsystem, "labels_count"),
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_count"),
 		"Github issues per labels.",
 		[]string{
 			labelOrg,
@@ -25,7 +30,7 @@ var (
 		nil,
 	)
 	issueStatesDesc *prometheus.Desc = prometheus.NewDesc(
-		prometheus.BuildFQName(namespace, subsystem, "states_count"),
+		prometheus.BuildFQName(namespace, subsystemIssue, "states_count"),
 		"Github issue states.",
 		[]string{
 			labelOrg,
@@ -34,61 +39,544 @@ var (
 		},
 		nil,
 	)
-)
-
-var (
-	issueLabelsLifetimeHistogramVec = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    prometheus.BuildFQName(namespace, subsystem, "labels_lifetime"),
-			Help:    "Github issue lifetime per labels.",
-			Buckets: prometheus.ExponentialBuckets(60*60*24, 2, 10),
+	issueLabelsAgeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_age"),
+		"Github open issue age per labels.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+		},
+		nil,
+	)
+	issueLabelsStaleDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_stale_count"),
+		"Github open issues per labels without activity for at least the given number of days.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+			labelDays,
+		},
+		nil,
+	)
+	issueStaleDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "stale_count"),
+		"Github open issues without activity for at least the given number of days.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelDays,
+		},
+		nil,
+	)
+	issueTimeToFirstResponseDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "time_to_first_response"),
+		"Time between creating Github issues and the first response by somebody other than the author.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	issueLabelsTimeToFirstResponseDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_time_to_first_response"),
+		"Time between creating Github issues and the first response by somebody other than the author per labels.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+		},
+		nil,
+	)
+	issueAwaitingFirstResponseDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "awaiting_first_response_count"),
+		"Open Github issues without response by somebody other than the author.",
+		[]string{
+			labelOrg,
+			labelRepo,
 		},
+		nil,
+	)
+	issueLabelsLifetimeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_lifetime"),
+		"Github issue lifetime per labels.",
 		[]string{
 			labelOrg,
 			labelRepo,
 			labelLabels,
 		},
+		nil,
 	)
 )
 
-func init() {
-	prometheus.MustRegister(issueLabelsLifetimeHistogramVec)
-}
+const (
+	// dimensionValueNone is the value of dimensions an issue has no label for.
+	dimensionValueNone = "none"
+	// issueSyncVersion is the version of the stored issue syncs. Version 2
+	// added the author, assignees and first response of issues.
+	issueSyncVersion = 2
+	// labelValueSeparator joins label values in map keys. It cannot be part of
+	// Github labels.
+	labelValueSeparator = "\xff"
+)
+
+var (
+	labelNameRegexp = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+var (
+	// defaultLifetimeBuckets ranges from one day to 512 days.
+	defaultLifetimeBuckets = prometheus.ExponentialBuckets(60*60*24, 2, 10)
+	// defaultFirstResponseBuckets ranges from 15 minutes to 512 hours.
+	defaultFirstResponseBuckets = prometheus.ExponentialBuckets(15*60, 2, 12)
+	// defaultStaleThresholds are 30, 90 and 180 days.
+	defaultStaleThresholds = []time.Duration{
+		30 * 24 * time.Hour,
+		90 * 24 * time.Hour,
+		180 * 24 * time.Hour,
+	}
+)
 
 type IssueConfig struct {
+	Discovery    *Discovery
 	GithubClient *github.Client
 	Logger       micrologger.Logger
-
+	// Store persists the issues of each repository and the cursor of their
+	// last sync, so that a restart does not cause a full sync.
+	Store store.Interface
+
+	// BreakdownLimit is the maximum number of assignees and authors exported
+	// individually per breakdown selector. Issues of other users are counted
+	// as _other. Defaults to 10.
+	BreakdownLimit int
+	// BreakdownSelectors are label selectors for which open issues are counted
+	// per assignee and author. See CustomLabels.
+	BreakdownSelectors []string
+	// BreakdownTeams are teams of the form org/team whose members are allowed
+	// to be exported individually in the breakdown. They are resolved using
+	// the Teams API on every refresh.
+	BreakdownTeams []string
+	// BreakdownUsers are the logins of users allowed to be exported
+	// individually in the breakdown. All users are allowed if neither users nor
+	// teams are given.
+	BreakdownUsers []string
+	// CustomLabels are label selectors, e.g. postmortem,team/* or kind/bug AND
+	// NOT wontfix. Issues matching a selector are exported using the normalized
+	// form of the selector as labels label. See selector for the grammar.
 	CustomLabels []string
+	// Dimensions are label prefixes exported as labels of the issue count. The
+	// dimension team turns the label team/batman into the label team="batman".
+	// Issues without a label of a dimension get the value none. Issues with
+	// multiple labels of a dimension are counted once per value.
+	Dimensions []string
+	// FirstResponseBudget is the maximum number of API calls per refresh used
+	// to fetch comments and events of issues to determine their first
+	// response. Defaults to 100.
+	FirstResponseBudget int
+	// FirstResponseEnabled enables collecting the time to the first response to
+	// issues created within the first response window.
+	FirstResponseEnabled bool
+	// FirstResponseWindow is the time since creation within which issues are
+	// checked for their first response. Defaults to 30 days.
+	FirstResponseWindow time.Duration
+	// LifetimeBuckets are the upper bounds in seconds of the buckets of the
+	// issue lifetime and open issue age histograms. Defaults to exponential
+	// buckets ranging from one day to 512 days.
+	LifetimeBuckets []float64
+	// Retention is the time after which closed issues are dropped. It also
+	// limits the initial sync to issues updated within the retention. Zero
+	// keeps all issues.
+	Retention time.Duration
+	// StaleThresholds are the times without activity after which open issues
+	// are counted as stale. Defaults to 30, 90 and 180 days.
+	StaleThresholds []time.Duration
 }
 
 type Issue struct {
+	discovery    *Discovery
 	githubClient *github.Client
 	logger       micrologger.Logger
+	store        store.Interface
+
+	issues   map[Repository]*issueSync
+	snapshot *snapshot
+
+	// breakdownAllowed are the users allowed to be exported individually in
+	// the breakdown, or nil if all users are allowed. breakdownTeamMembers
+	// holds the members of each team resolved so far.
+	breakdownAllowed     map[string]bool
+	breakdownTeamMembers map[team][]string
+	// firstResponseBudget is the API call budget left in the current refresh.
+	firstResponseBudget int
+
+	breakdownLimit                int
+	breakdownSelectors            []selector
+	breakdownTeams                []team
+	breakdownUsers                []string
+	countDesc                     *prometheus.Desc
+	customLabels                  []selector
+	dimensions                    []string
+	firstResponseBudgetPerRefresh int
+	firstResponseEnabled          bool
+	firstResponseWindow           time.Duration
+	lifetimeBuckets               []float64
+	retention                     time.Duration
+	staleThresholds               []time.Duration
+}
+
+// issueRecord is the compact representation of a Github issue kept in memory
+// between refreshes. issueSyncVersion has to be incremented whenever it
+// changes.
+type issueRecord struct {
+	Assignees []string
+	Author    string
+	ClosedAt  time.Time
+	CreatedAt time.Time
+	Labels    []string
+	Number    int
+	State     string
+	UpdatedAt time.Time
+
+	// FirstResponseAt is the time of the first response by somebody other than
+	// the author. It is zero as long as there was no response or the issue was
+	// not checked yet.
+	FirstResponseAt time.Time
+	// ResponseCheckedAt is the update time of the issue when it was last
+	// checked for its first response.
+	ResponseCheckedAt time.Time
+}
 
-	customLabels []string
+// issueSync holds all issues of a repository known so far and the cursor of
+// the last successful sync. Only issues updated since the cursor are fetched
+// on the next refresh.
+type issueSync struct {
+	Cursor time.Time
+	Issues map[int]issueRecord
+	// Version is the issueSyncVersion the sync was stored with.
+	Version int
 }
 
 func NewIssue(config IssueConfig) (*Issue, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
 	if config.GithubClient == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
 	}
 	if config.Logger == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
 	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	if len(config.LifetimeBuckets) == 0 {
+		config.LifetimeBuckets = defaultLifetimeBuckets
+	}
+	if !sort.Float64sAreSorted(config.LifetimeBuckets) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.LifetimeBuckets must be in increasing order", config)
+	}
+	if config.Retention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Retention must not be negative", config)
+	}
+	if config.FirstResponseBudget == 0 {
+		config.FirstResponseBudget = 100
+	}
+	if config.FirstResponseBudget < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FirstResponseBudget must not be negative", config)
+	}
+	if config.FirstResponseWindow == 0 {
+		config.FirstResponseWindow = 30 * 24 * time.Hour
+	}
+	if config.FirstResponseWindow < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FirstResponseWindow must not be negative", config)
+	}
+	if config.BreakdownLimit == 0 {
+		config.BreakdownLimit = 10
+	}
+	if config.BreakdownLimit < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.BreakdownLimit must not be negative", config)
+	}
+	if len(config.StaleThresholds) == 0 {
+		config.StaleThresholds = defaultStaleThresholds
+	}
+	for n, t := range config.StaleThresholds {
+		if t <= 0 {
+			return nil, microerror.Maskf(invalidConfigError, "%T.StaleThresholds[%d] must be positive", config, n)
+		}
+	}
+
+	var breakdownSelectors []selector
+	for n, l := range config.BreakdownSelectors {
+		s, err := parseSelector(l)
+		if err != nil {
+			return nil, microerror.Maskf(err, "%T.BreakdownSelectors[%d]", config, n)
+		}
+
+		breakdownSelectors = append(breakdownSelectors, s)
+	}
+
+	var breakdownTeams []team
+	for n, s := range config.BreakdownTeams {
+		t, err := newTeam(s)
+		if err != nil {
+			return nil, microerror.Maskf(err, "%T.BreakdownTeams[%d]", config, n)
+		}
+
+		breakdownTeams = append(breakdownTeams, t)
+	}
+
+	// All users are exported individually, up to the limit, unless users or
+	// teams restrict them.
+	var breakdownAllowed map[string]bool
+	if len(config.BreakdownUsers) != 0 || len(config.BreakdownTeams) != 0 {
+		breakdownAllowed = map[string]bool{}
+		for _, u := range config.BreakdownUsers {
+			breakdownAllowed[u] = true
+		}
+	}
+
+	var customLabels []selector
+	for n, l := range config.CustomLabels {
+		s, err := parseSelector(l)
+		if err != nil {
+			return nil, microerror.Maskf(err, "%T.CustomLabels[%d]", config, n)
+		}
+
+		customLabels = append(customLabels, s)
+	}
+
+	// The issue count is only exported if dimensions are configured. Its labels
+	// depend on the dimensions, so its description cannot be static.
+	var countDesc *prometheus.Desc
+	if len(config.Dimensions) != 0 {
+		labels := []string{
+			labelOrg,
+			labelRepo,
+		}
+		seen := map[string]bool{
+			labelOrg:   true,
+			labelRepo:  true,
+			labelState: true,
+		}
+		for n, d := range config.Dimensions {
+			if !labelNameRegexp.MatchString(d) {
+				return nil, microerror.Maskf(invalidConfigError, "%T.Dimensions[%d] must be a valid Prometheus label name but got %#q", config, n, d)
+			}
+			// Label names starting with __ are reserved for internal use by
+			// Prometheus.
+			if strings.HasPrefix(d, "__") {
+				return nil, microerror.Maskf(invalidConfigError, "%T.Dimensions[%d] must not start with __ but got %#q", config, n, d)
+			}
+			if seen[d] {
+				return nil, microerror.Maskf(invalidConfigError, "%T.Dimensions[%d] must not be a duplicate or reserved label name but got %#q", config, n, d)
+			}
+			seen[d] = true
+
+			labels = append(labels, d)
+		}
+		labels = append(labels, labelState)
+
+		countDesc = prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystemIssue, "count"),
+			"Github issues per dimension.",
+			labels,
+			nil,
+		)
+	}
 
 	i := &Issue{
+		discovery:    config.Discovery,
 		githubClient: config.GithubClient,
 		logger:       config.Logger,
-
-		customLabels: config.CustomLabels,
+		store:        config.Store,
+
+		issues:   map[Repository]*issueSync{},
+		snapshot: newSnapshot(),
+
+		breakdownAllowed:     breakdownAllowed,
+		breakdownTeamMembers: map[team][]string{},
+
+		breakdownLimit:                config.BreakdownLimit,
+		breakdownSelectors:            breakdownSelectors,
+		breakdownTeams:                breakdownTeams,
+		breakdownUsers:                config.BreakdownUsers,
+		countDesc:                     countDesc,
+		customLabels:                  customLabels,
+		dimensions:                    config.Dimensions,
+		firstResponseBudgetPerRefresh: config.FirstResponseBudget,
+		firstResponseEnabled:          config.FirstResponseEnabled,
+		firstResponseWindow:           config.FirstResponseWindow,
+		lifetimeBuckets:               config.LifetimeBuckets,
+		retention:                     config.Retention,
+		staleThresholds:               config.StaleThresholds,
 	}
 
 	return i, nil
 }
 
+// Collect emits the metrics of the latest snapshot. See Refresh.
 func (i *Issue) Collect(ch chan<- prometheus.Metric) error {
-	ctx := context.Background()
+	i.snapshot.Collect(ch)
+	return nil
+}
+
+func (i *Issue) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- issueLabelsDesc
+	ch <- issueStatesDesc
+	ch <- issueLabelsAgeDesc
+	ch <- issueLabelsStaleDesc
+	ch <- issueStaleDesc
+	ch <- issueTimeToFirstResponseDesc
+	ch <- issueLabelsTimeToFirstResponseDesc
+	ch <- issueAwaitingFirstResponseDesc
+	ch <- issueLabelsLifetimeDesc
+	ch <- issueLabelsAssigneeDesc
+	ch <- issueLabelsAuthorDesc
+	if i.countDesc != nil {
+		ch <- i.countDesc
+	}
+	return nil
+}
+
+// Refresh fetches the issues of all discovered repositories updated since the
+// last refresh, merges them into the issues known so far and replaces the
+// snapshot emitted by Collect.
+func (i *Issue) Refresh(ctx context.Context) error {
+	repositories := i.discovery.Repositories()
+
+	// Repositories which are not discovered anymore do not need to be synced
+	// anymore.
+	for r := range i.issues {
+		if !containsRepository(repositories, r) {
+			delete(i.issues, r)
+		}
+	}
+
+	i.refreshAllowedUsers(ctx)
+
+	// The API call budget to determine first responses is shared by all
+	// repositories.
+	i.firstResponseBudget = i.firstResponseBudgetPerRefresh
+
+	err := i.snapshot.Refresh(ctx, i.logger, repositories, i.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (i *Issue) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	synced, ok := i.issues[r]
+	if !ok {
+		var err error
+		synced, err = i.loadSync(ctx, r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	since := synced.Cursor
+	if since.IsZero() && i.retention != 0 {
+		since = now.Add(-i.retention)
+	}
+
+	updated, cursor, err := i.listIssues(ctx, r, "all", since)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Open issues are counted regardless of the retention. So the initial sync
+	// also lists the open issues which were not updated within the retention,
+	// e.g. to count issues which are stale for longer than the retention.
+	if synced.Cursor.IsZero() && !since.IsZero() {
+		open, openCursor, err := i.listIssues(ctx, r, "open", time.Time{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		updated = append(open, updated...)
+		if openCursor.After(cursor) {
+			cursor = openCursor
+		}
+	}
+
+	for _, record := range updated {
+		// The first response is not part of the listed issues, so it has to be
+		// kept when merging them.
+		if previous, ok := synced.Issues[record.Number]; ok {
+			record.FirstResponseAt = previous.FirstResponseAt
+			record.ResponseCheckedAt = previous.ResponseCheckedAt
+		}
+
+		synced.Issues[record.Number] = record
+	}
+	if cursor.After(synced.Cursor) {
+		synced.Cursor = cursor
+	}
+
+	if i.retention != 0 {
+		for n, record := range synced.Issues {
+			if record.State == "closed" && record.ClosedAt.Before(now.Add(-i.retention)) {
+				delete(synced.Issues, n)
+			}
+		}
+	}
+
+	if i.firstResponseEnabled {
+		i.checkFirstResponses(ctx, r, synced, now)
+	}
+
+	i.issues[r] = synced
+
+	// Failing to persist the sync only means that the next restart has to sync
+	// more issues, so the refresh does not fail because of it.
+	err = i.store.Put(issueStoreKey(r), synced)
+	if err != nil {
+		i.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed storing issues of repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	metrics := i.issueMetrics(r, synced.Issues, now)
+	metrics = append(metrics, i.breakdownMetrics(r, synced.Issues)...)
+
+	return metrics, nil
+}
+
+// loadSync returns the sync of the given repository persisted by a previous
+// run of the exporter, or an empty sync if there is none.
+func (i *Issue) loadSync(ctx context.Context, r Repository) (*issueSync, error) {
+	synced := &issueSync{}
+
+	ok, err := i.store.Get(issueStoreKey(r), synced)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Issues stored with another version lack fields of their records, which
+	// would never be filled since the issues are not fetched again unless
+	// they are updated. So they are synced again from scratch.
+	if ok && synced.Version != issueSyncVersion {
+		i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("discarding stored issues of version %d for repository %#q", synced.Version, r.String()))
+		synced = &issueSync{}
+	} else if ok {
+		i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("loaded %d stored issues for repository %#q", len(synced.Issues), r.String()))
+	}
+	if synced.Issues == nil {
+		synced.Issues = map[int]issueRecord{}
+	}
+	synced.Version = issueSyncVersion
+
+	return synced, nil
+}
+
+// listIssues returns the issues of the given repository in the given state
+// updated since the given time, together with the most recent update time
+// seen. Pull requests are skipped. A zero since lists all issues.
+func (i *Issue) listIssues(ctx context.Context, r Repository, state string, since time.Time) ([]issueRecord, time.Time, error) {
 	opts := &github.IssueListByRepoOptions{
 		ListOptions: github.ListOptions{
 			Page: 1,
@@ -97,130 +585,321 @@ func (i *Issue) Collect(ch chan<- prometheus.Metric) error {
 			// https://developer.github.com/v3/search/#about-the-search-api.
 			PerPage: 1000,
 		},
-		Since: time.Now().AddDate(-1, 0, 0), // one year ago
-		State: "all",
+		Since: since,
+		State: state,
+	}
+
+	var cursor time.Time
+	var records []issueRecord
+
+	for {
+		issues, res, err := i.githubClient.Issues.ListByRepo(ctx, r.Org, r.Name, opts)
+		if err != nil {
+			return nil, time.Time{}, microerror.Mask(err)
+		}
+
+		i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d issues of page %2d for repository %#q", len(issues), opts.Page, r.String()))
+
+		for _, issue := range issues {
+			// The cursor is based on the update times given by Github, so that it
+			// does not depend on the exporter's clock.
+			if issue.GetUpdatedAt().After(cursor) {
+				cursor = issue.GetUpdatedAt()
+			}
+
+			if issue.IsPullRequest() {
+				continue
+			}
+
+			record := issueRecord{
+				Author:    issue.GetUser().GetLogin(),
+				ClosedAt:  issue.GetClosedAt(),
+				CreatedAt: issue.GetCreatedAt(),
+				Number:    issue.GetNumber(),
+				State:     issue.GetState(),
+				UpdatedAt: issue.GetUpdatedAt(),
+			}
+			for _, assignee := range issue.Assignees {
+				record.Assignees = append(record.Assignees, assignee.GetLogin())
+			}
+			for _, label := range issue.Labels {
+				record.Labels = append(record.Labels, label.GetName())
+			}
+
+			records = append(records, record)
+		}
+
+		// Manage the paging mechanism. When NextPage is 0 we iterated through all
+		// the pages and can stop loopong through. As long as there are pages left
+		// we assign the next page to our options structure as given by the current
+		// response.
+		if res.NextPage == 0 {
+			i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collected all issues for repository %#q", r.String()))
+			break
+		}
+		opts.Page = res.NextPage
 	}
 
+	return records, cursor, nil
+}
+
+func (i *Issue) issueMetrics(r Repository, issues map[int]issueRecord, now time.Time) []prometheus.Metric {
 	type key struct {
 		Label string
 		State string
 	}
+	type staleKey struct {
+		Label string
+		Days  string
+	}
 
+	var awaitingFirstResponse float64
+	issueCounts := map[string]float64{}
 	issueLabels := map[key]float64{}
+	issueLabelsAge := map[string]*histogram{}
+	issueLabelsLifetime := map[string]*histogram{}
+	issueLabelsStale := map[staleKey]float64{}
+	issueLabelsTimeToFirstResponse := map[string]*histogram{}
+	issueStale := map[string]float64{}
 	issueStates := map[string]float64{}
+	timeToFirstResponse := newHistogram(defaultFirstResponseBuckets)
 
-	for {
-		issues, res, err := i.githubClient.Issues.ListByRepo(ctx, githubOrg, githubRepo, opts)
-		if err != nil {
-			return microerror.Mask(err)
+	observe := func(m map[string]*histogram, label string, v float64) {
+		h, ok := m[label]
+		if !ok {
+			h = newHistogram(i.lifetimeBuckets)
+			m[label] = h
 		}
 
-		i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d issues of page %2d", len(issues), opts.Page))
+		h.Observe(v)
+	}
 
-		for _, issue := range issues {
-			if issue.IsPullRequest() {
-				continue
+	// inFirstResponseWindow returns whether the first response of the given
+	// issue is part of the time to first response distribution, which only
+	// covers recently created issues.
+	inFirstResponseWindow := func(issue issueRecord) bool {
+		return i.firstResponseEnabled && !issue.CreatedAt.Before(now.Add(-i.firstResponseWindow))
+	}
+
+	// staleDays returns the thresholds in days the given issue is stale for.
+	staleDays := func(issue issueRecord) []string {
+		var days []string
+		for _, t := range i.staleThresholds {
+			if issue.UpdatedAt.Before(now.Add(-t)) {
+				days = append(days, strconv.FormatFloat(t.Hours()/24, 'f', -1, 64))
 			}
+		}
 
-			for _, label := range issue.Labels {
-				{
-					k := key{
-						Label: label.GetName(),
-						State: issue.GetState(),
-					}
-					issueLabels[k] = issueLabels[k] + 1
-				}
+		return days
+	}
 
-				if issue.GetState() == "closed" {
-					f := float64(issue.GetClosedAt().Unix() - issue.GetCreatedAt().Unix())
-					issueLabelsLifetimeHistogramVec.WithLabelValues(githubOrg, githubRepo, label.GetName()).Observe(f)
-				}
+	// observeLabel observes the given issue for the given label, which is either
+	// a label of the issue or the normalized form of a selector it matches.
+	observeLabel := func(label string, issue issueRecord) {
+		{
+			k := key{
+				Label: label,
+				State: issue.State,
 			}
+			issueLabels[k] = issueLabels[k] + 1
+		}
 
-			for _, selector := range i.customLabels {
-				if !hasLabels(issue, selector) {
-					continue
-				}
+		if issue.State == "closed" {
+			observe(issueLabelsLifetime, label, issue.ClosedAt.Sub(issue.CreatedAt).Seconds())
+		}
 
-				{
-					k := key{
-						Label: selector,
-						State: issue.GetState(),
-					}
-					issueLabels[k] = issueLabels[k] + 1
+		if issue.State == "open" {
+			observe(issueLabelsAge, label, now.Sub(issue.CreatedAt).Seconds())
+
+			for _, d := range staleDays(issue) {
+				k := staleKey{
+					Label: label,
+					Days:  d,
 				}
+				issueLabelsStale[k] = issueLabelsStale[k] + 1
+			}
+		}
+	}
+
+	for _, issue := range issues {
+		for _, label := range issue.Labels {
+			observeLabel(label, issue)
+		}
 
-				if issue.GetState() == "closed" {
-					f := float64(issue.GetClosedAt().Unix() - issue.GetCreatedAt().Unix())
-					issueLabelsLifetimeHistogramVec.WithLabelValues(githubOrg, githubRepo, selector).Observe(f)
+		for _, s := range i.customLabels {
+			if !hasLabels(issue, s) {
+				continue
+			}
+
+			observeLabel(s.String(), issue)
+
+			if inFirstResponseWindow(issue) && !issue.FirstResponseAt.IsZero() {
+				h, ok := issueLabelsTimeToFirstResponse[s.String()]
+				if !ok {
+					h = newHistogram(defaultFirstResponseBuckets)
+					issueLabelsTimeToFirstResponse[s.String()] = h
 				}
+
+				h.Observe(issue.FirstResponseAt.Sub(issue.CreatedAt).Seconds())
 			}
+		}
 
-			{
-				issueStates[issue.GetState()] = issueStates[issue.GetState()] + 1
+		if inFirstResponseWindow(issue) {
+			if !issue.FirstResponseAt.IsZero() {
+				timeToFirstResponse.Observe(issue.FirstResponseAt.Sub(issue.CreatedAt).Seconds())
+			} else if issue.State == "open" && !issue.ResponseCheckedAt.IsZero() {
+				awaitingFirstResponse++
 			}
 		}
 
-		// Manage the paging mechanism. When NextPage is 0 we iterated through all
-		// the pages and can stop loopong through. As long as there are pages left
-		// we assign the next page to our options structure as given by the current
-		// response.
-		if res.NextPage == 0 {
-			i.logger.LogCtx(ctx, "level", "debug", "message", "collected all issues")
-			break
+		if i.countDesc != nil {
+			for _, values := range dimensionValues(issue.Labels, i.dimensions) {
+				k := strings.Join(append(values, issue.State), labelValueSeparator)
+				issueCounts[k] = issueCounts[k] + 1
+			}
+		}
+
+		if issue.State == "open" {
+			for _, d := range staleDays(issue) {
+				issueStale[d] = issueStale[d] + 1
+			}
+		}
+
+		{
+			issueStates[issue.State] = issueStates[issue.State] + 1
 		}
-		opts.Page = res.NextPage
 	}
 
+	var metrics []prometheus.Metric
+
 	for k, v := range issueLabels {
-		ch <- prometheus.MustNewConstMetric(
+		m := prometheus.MustNewConstMetric(
 			issueLabelsDesc,
 			prometheus.GaugeValue,
 			v,
-			githubOrg,
-			githubRepo,
+			r.Org,
+			r.Name,
 			k.Label,
 			k.State,
 		)
+		metrics = append(metrics, m)
+	}
+
+	for k, v := range issueCounts {
+		labelValues := append([]string{r.Org, r.Name}, strings.Split(k, labelValueSeparator)...)
+
+		m := prometheus.MustNewConstMetric(
+			i.countDesc,
+			prometheus.GaugeValue,
+			v,
+			labelValues...,
+		)
+		metrics = append(metrics, m)
 	}
 
 	for k, v := range issueStates {
-		ch <- prometheus.MustNewConstMetric(
+		m := prometheus.MustNewConstMetric(
 			issueStatesDesc,
 			prometheus.GaugeValue,
 			v,
-			githubOrg,
-			githubRepo,
+			r.Org,
+			r.Name,
 			k,
 		)
+		metrics = append(metrics, m)
 	}
 
-	return nil
-}
+	for k, v := range issueLabelsStale {
+		m := prometheus.MustNewConstMetric(
+			issueLabelsStaleDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k.Label,
+			k.Days,
+		)
+		metrics = append(metrics, m)
+	}
 
-func (i *Issue) Describe(ch chan<- *prometheus.Desc) error {
-	ch <- issueLabelsDesc
-	ch <- issueStatesDesc
-	return nil
+	for k, v := range issueStale {
+		m := prometheus.MustNewConstMetric(
+			issueStaleDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, h := range issueLabelsAge {
+		metrics = append(metrics, h.Metric(issueLabelsAgeDesc, r.Org, r.Name, k))
+	}
+
+	if i.firstResponseEnabled {
+		metrics = append(metrics, timeToFirstResponse.Metric(issueTimeToFirstResponseDesc, r.Org, r.Name))
+
+		m := prometheus.MustNewConstMetric(
+			issueAwaitingFirstResponseDesc,
+			prometheus.GaugeValue,
+			awaitingFirstResponse,
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, h := range issueLabelsTimeToFirstResponse {
+		metrics = append(metrics, h.Metric(issueLabelsTimeToFirstResponseDesc, r.Org, r.Name, k))
+	}
+
+	for k, h := range issueLabelsLifetime {
+		metrics = append(metrics, h.Metric(issueLabelsLifetimeDesc, r.Org, r.Name, k))
+	}
+
+	return metrics
 }
 
-func hasLabels(issue *github.Issue, selector string) bool {
-	selectorLabels := strings.Split(selector, ",")
+func issueStoreKey(r Repository) string {
+	return "issue/" + r.String()
+}
 
-	for _, selectorLabel := range selectorLabels {
-		found := false
+// dimensionValues returns all combinations of the values the given labels have
+// for the given dimensions. The value of a dimension is the remainder of a
+// label starting with the dimension followed by a slash. Dimensions without
+// value get dimensionValueNone.
+func dimensionValues(labels []string, dimensions []string) [][]string {
+	combinations := [][]string{
+		{},
+	}
 
-		for _, issueLabel := range issue.Labels {
-			if issueLabel.GetName() == selectorLabel {
-				found = true
-				break
+	for _, d := range dimensions {
+		var values []string
+		for _, l := range labels {
+			if strings.HasPrefix(l, d+"/") && len(l) > len(d)+1 {
+				values = append(values, strings.TrimPrefix(l, d+"/"))
 			}
 		}
+		if len(values) == 0 {
+			values = []string{dimensionValueNone}
+		}
+		sort.Strings(values)
 
-		if !found {
-			return false
+		var next [][]string
+		for _, c := range combinations {
+			for _, v := range values {
+				n := append(append([]string{}, c...), v)
+				next = append(next, n)
+			}
 		}
+		combinations = next
 	}
 
-	return true
+	return combinations
+}
+
+func hasLabels(issue issueRecord, s selector) bool {
+	return s.Matches(issue.Labels)
 }
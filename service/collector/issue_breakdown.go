@@ -0,0 +1,282 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	issueLabelsAssigneeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_assignee_count"),
+		"Github open issues per labels and assignee.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+			labelAssignee,
+		},
+		nil,
+	)
+	issueLabelsAuthorDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemIssue, "labels_author_count"),
+		"Github open issues per labels and author.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+			labelAuthor,
+		},
+		nil,
+	)
+)
+
+const (
+	// userValueNone is the value of issues without assignee or author. Github
+	// logins cannot contain underscores, so it cannot collide with a user.
+	userValueNone = "_none"
+	// userValueOther is the value of users which are not exported
+	// individually, either because they are not allowed or because they are
+	// not among the users with the most issues. Like userValueNone it cannot
+	// collide with a user.
+	userValueOther = "_other"
+)
+
+// team identifies a Github team by its organization and its slug, e.g.
+// giantswarm/batman.
+type team struct {
+	Org  string
+	Slug string
+}
+
+func newTeam(s string) (team, error) {
+	split := strings.Split(s, "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return team{}, microerror.Maskf(invalidConfigError, "team %#q must be of the form org/team", s)
+	}
+
+	t := team{
+		Org:  split[0],
+		Slug: split[1],
+	}
+
+	return t, nil
+}
+
+func (t team) String() string {
+	return t.Org + "/" + t.Slug
+}
+
+// refreshAllowedUsers resolves the members of the configured teams and
+// combines them with the configured users. Members of teams which cannot be
+// resolved are kept from the previous refresh, so that a failing Teams API
+// does not move all their issues into the other bucket.
+func (i *Issue) refreshAllowedUsers(ctx context.Context) {
+	if len(i.breakdownTeams) == 0 {
+		return
+	}
+
+	for _, t := range i.breakdownTeams {
+		members, err := i.listTeamMembers(ctx, t)
+		if err != nil {
+			i.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed resolving members of team %#q, keeping previous members", t.String()), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		i.breakdownTeamMembers[t] = members
+	}
+
+	allowed := map[string]bool{}
+	for _, u := range i.breakdownUsers {
+		allowed[u] = true
+	}
+	for _, members := range i.breakdownTeamMembers {
+		for _, m := range members {
+			allowed[m] = true
+		}
+	}
+
+	i.breakdownAllowed = allowed
+}
+
+// listTeamMembers returns the logins of the members of the given team. The
+// Teams API identifies teams by ID, so the team is looked up by its slug in
+// the teams of its organization first.
+func (i *Issue) listTeamMembers(ctx context.Context, t team) ([]string, error) {
+	var id int64
+	{
+		opts := &github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		}
+
+		for id == 0 {
+			teams, res, err := i.githubClient.Teams.ListTeams(ctx, t.Org, opts)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, gt := range teams {
+				if gt.GetSlug() == t.Slug {
+					id = gt.GetID()
+					break
+				}
+			}
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+
+		if id == 0 {
+			return nil, microerror.Maskf(notFoundError, "team %#q", t.String())
+		}
+	}
+
+	var members []string
+	{
+		opts := &github.TeamListTeamMembersOptions{
+			ListOptions: github.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+		}
+
+		for {
+			users, res, err := i.githubClient.Teams.ListTeamMembers(ctx, id, opts)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			for _, u := range users {
+				members = append(members, u.GetLogin())
+			}
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	return members, nil
+}
+
+// limitUsers returns the given issue counts per user reduced to the users
+// exported individually. Users not contained in the given allowed users are
+// counted as other, unless allowed is nil. Of the remaining users, only the
+// given number of users with the most issues are kept and the others are
+// counted as other, too. The count of issues without user is always kept.
+func limitUsers(counts map[string]float64, allowed map[string]bool, limit int) map[string]float64 {
+	limited := map[string]float64{}
+
+	var users []string
+	for u, v := range counts {
+		if u == userValueNone {
+			limited[u] = v
+			continue
+		}
+		if allowed != nil && !allowed[u] {
+			limited[userValueOther] += v
+			continue
+		}
+
+		users = append(users, u)
+	}
+
+	// Users with the same number of issues are ordered by name, so that the
+	// exported users do not change between refreshes without reason.
+	sort.Slice(users, func(a, b int) bool {
+		if counts[users[a]] != counts[users[b]] {
+			return counts[users[a]] > counts[users[b]]
+		}
+		return users[a] < users[b]
+	})
+
+	for n, u := range users {
+		if n < limit {
+			limited[u] = counts[u]
+		} else {
+			limited[userValueOther] += counts[u]
+		}
+	}
+
+	return limited
+}
+
+// breakdownMetrics returns the open issue counts per assignee and author of
+// the given issues matching the breakdown selectors.
+func (i *Issue) breakdownMetrics(r Repository, issues map[int]issueRecord) []prometheus.Metric {
+	assignees := map[string]map[string]float64{}
+	authors := map[string]map[string]float64{}
+
+	for _, issue := range issues {
+		if issue.State != "open" {
+			continue
+		}
+
+		for _, s := range i.breakdownSelectors {
+			if !hasLabels(issue, s) {
+				continue
+			}
+
+			if _, ok := assignees[s.String()]; !ok {
+				assignees[s.String()] = map[string]float64{}
+				authors[s.String()] = map[string]float64{}
+			}
+
+			if len(issue.Assignees) == 0 {
+				assignees[s.String()][userValueNone]++
+			}
+			for _, a := range issue.Assignees {
+				assignees[s.String()][a]++
+			}
+
+			author := issue.Author
+			if author == "" {
+				author = userValueNone
+			}
+			authors[s.String()][author]++
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	for label, counts := range assignees {
+		for u, v := range limitUsers(counts, i.breakdownAllowed, i.breakdownLimit) {
+			m := prometheus.MustNewConstMetric(
+				issueLabelsAssigneeDesc,
+				prometheus.GaugeValue,
+				v,
+				r.Org,
+				r.Name,
+				label,
+				u,
+			)
+			metrics = append(metrics, m)
+		}
+	}
+
+	for label, counts := range authors {
+		for u, v := range limitUsers(counts, i.breakdownAllowed, i.breakdownLimit) {
+			m := prometheus.MustNewConstMetric(
+				issueLabelsAuthorDesc,
+				prometheus.GaugeValue,
+				v,
+				r.Org,
+				r.Name,
+				label,
+				u,
+			)
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics
+}
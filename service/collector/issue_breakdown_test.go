@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Collector_Issue_limitUsers(t *testing.T) {
+	testCases := []struct {
+		name           string
+		counts         map[string]float64
+		allowed        map[string]bool
+		limit          int
+		expectedResult map[string]float64
+	}{
+		{
+			name: "case 0 users within the limit",
+			counts: map[string]float64{
+				"alice": 3,
+				"bob":   1,
+				"_none": 2,
+			},
+			allowed: nil,
+			limit:   10,
+			expectedResult: map[string]float64{
+				"alice": 3,
+				"bob":   1,
+				"_none": 2,
+			},
+		},
+		{
+			name: "case 1 users exceeding the limit",
+			counts: map[string]float64{
+				"alice": 3,
+				"bob":   1,
+				"carol": 5,
+				"dave":  1,
+			},
+			allowed: nil,
+			limit:   2,
+			expectedResult: map[string]float64{
+				"alice":  3,
+				"carol":  5,
+				"_other": 2,
+			},
+		},
+		{
+			name: "case 2 users with the same count ordered by name",
+			counts: map[string]float64{
+				"bob":   1,
+				"alice": 1,
+				"carol": 1,
+			},
+			allowed: nil,
+			limit:   1,
+			expectedResult: map[string]float64{
+				"alice":  1,
+				"_other": 2,
+			},
+		},
+		{
+			name: "case 3 users not allowed",
+			counts: map[string]float64{
+				"alice": 3,
+				"bob":   1,
+				"carol": 5,
+				"_none": 4,
+			},
+			allowed: map[string]bool{
+				"alice": true,
+				"bob":   true,
+			},
+			limit: 10,
+			expectedResult: map[string]float64{
+				"alice":  3,
+				"bob":    1,
+				"_none":  4,
+				"_other": 5,
+			},
+		},
+		{
+			name: "case 4 allowed users exceeding the limit",
+			counts: map[string]float64{
+				"alice": 3,
+				"bob":   1,
+				"carol": 5,
+			},
+			allowed: map[string]bool{
+				"alice": true,
+				"bob":   true,
+			},
+			limit: 1,
+			expectedResult: map[string]float64{
+				"alice":  3,
+				"_other": 6,
+			},
+		},
+		{
+			name: "case 5 users named other and none do not collide with the sentinels",
+			counts: map[string]float64{
+				"_none": 1,
+				"alice": 2,
+				"bob":   1,
+				"none":  3,
+				"other": 4,
+			},
+			allowed: nil,
+			limit:   2,
+			expectedResult: map[string]float64{
+				"_none":  1,
+				"_other": 3,
+				"none":   3,
+				"other":  4,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := limitUsers(tc.counts, tc.allowed, tc.limit)
+
+			if !cmp.Equal(result, tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
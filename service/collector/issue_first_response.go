@@ -0,0 +1,149 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/google/go-github/github"
+)
+
+// checkFirstResponses determines the first response to the issues of the given
+// sync which were created within the first response window. Issues are only
+// checked again once they were updated, because new comments, labels and
+// assignments update issues. Checking an issue requires listing its comments
+// and events, which is limited by the API call budget of the current refresh.
+// Issues which cannot be checked anymore are checked on the next refresh.
+func (i *Issue) checkFirstResponses(ctx context.Context, r Repository, synced *issueSync, now time.Time) {
+	var candidates []issueRecord
+	for _, record := range synced.Issues {
+		if record.Author == "" || !record.FirstResponseAt.IsZero() {
+			continue
+		}
+		if record.CreatedAt.Before(now.Add(-i.firstResponseWindow)) {
+			continue
+		}
+		if !record.UpdatedAt.After(record.ResponseCheckedAt) {
+			continue
+		}
+
+		candidates = append(candidates, record)
+	}
+
+	// Older issues are checked first, because they leave the window first.
+	sort.Slice(candidates, func(a, b int) bool {
+		return candidates[a].CreatedAt.Before(candidates[b].CreatedAt)
+	})
+
+	for n, record := range candidates {
+		at, ok, err := i.fetchFirstResponse(ctx, r, record)
+		if err != nil {
+			i.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed checking first response of issue %d of repository %#q", record.Number, r.String()), "stack", fmt.Sprintf("%#v", err))
+			return
+		}
+		if !ok {
+			i.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("API call budget exhausted, %d issues of repository %#q are checked for first responses on the next refresh", len(candidates)-n, r.String()))
+			return
+		}
+
+		record.FirstResponseAt = at
+		record.ResponseCheckedAt = record.UpdatedAt
+		synced.Issues[record.Number] = record
+	}
+}
+
+// fetchFirstResponse returns the time of the first response to the given
+// issue, which is zero if there was no response yet. It returns false if the
+// API call budget was exhausted before the issue could be checked completely.
+func (i *Issue) fetchFirstResponse(ctx context.Context, r Repository, record issueRecord) (time.Time, bool, error) {
+	var comments []*github.IssueComment
+	{
+		opts := &github.IssueListCommentsOptions{
+			ListOptions: github.ListOptions{
+				Page:    1,
+				PerPage: 100,
+			},
+			Direction: "asc",
+			Sort:      "created",
+		}
+
+		for {
+			if i.firstResponseBudget <= 0 {
+				return time.Time{}, false, nil
+			}
+			i.firstResponseBudget--
+
+			list, res, err := i.githubClient.Issues.ListComments(ctx, r.Org, r.Name, record.Number, opts)
+			if err != nil {
+				return time.Time{}, false, microerror.Mask(err)
+			}
+			comments = append(comments, list...)
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	var events []*github.IssueEvent
+	{
+		opts := &github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		}
+
+		for {
+			if i.firstResponseBudget <= 0 {
+				return time.Time{}, false, nil
+			}
+			i.firstResponseBudget--
+
+			list, res, err := i.githubClient.Issues.ListIssueEvents(ctx, r.Org, r.Name, record.Number, opts)
+			if err != nil {
+				return time.Time{}, false, microerror.Mask(err)
+			}
+			events = append(events, list...)
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	return firstResponse(record.Author, comments, events), true, nil
+}
+
+// firstResponse returns the time of the first comment of somebody other than
+// the given author, or the first time somebody other than the author labeled
+// or assigned the issue, whatever happened first. It returns zero if there was
+// no response yet.
+func firstResponse(author string, comments []*github.IssueComment, events []*github.IssueEvent) time.Time {
+	var first time.Time
+
+	respond := func(at time.Time) {
+		if first.IsZero() || at.Before(first) {
+			first = at
+		}
+	}
+
+	for _, c := range comments {
+		if c.GetUser().GetLogin() != author {
+			respond(c.GetCreatedAt())
+		}
+	}
+
+	for _, e := range events {
+		if e.GetEvent() != "labeled" && e.GetEvent() != "assigned" {
+			continue
+		}
+		if e.GetActor().GetLogin() != author {
+			respond(e.GetCreatedAt())
+		}
+	}
+
+	return first
+}
@@ -0,0 +1,102 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func Test_Collector_Issue_firstResponse(t *testing.T) {
+	created := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	comment := func(login string, d time.Duration) *github.IssueComment {
+		at := created.Add(d)
+		return &github.IssueComment{
+			CreatedAt: &at,
+			User:      &github.User{Login: github.String(login)},
+		}
+	}
+	event := func(login string, name string, d time.Duration) *github.IssueEvent {
+		at := created.Add(d)
+		return &github.IssueEvent{
+			Actor:     &github.User{Login: github.String(login)},
+			CreatedAt: &at,
+			Event:     github.String(name),
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		author         string
+		comments       []*github.IssueComment
+		events         []*github.IssueEvent
+		expectedResult time.Time
+	}{
+		{
+			name:           "case 0 issue without comments and events",
+			author:         "alice",
+			expectedResult: time.Time{},
+		},
+		{
+			name:   "case 1 issue with comments of the author only",
+			author: "alice",
+			comments: []*github.IssueComment{
+				comment("alice", time.Hour),
+				comment("alice", 2*time.Hour),
+			},
+			expectedResult: time.Time{},
+		},
+		{
+			name:   "case 2 issue with a comment of somebody else",
+			author: "alice",
+			comments: []*github.IssueComment{
+				comment("alice", time.Hour),
+				comment("bob", 2*time.Hour),
+				comment("carol", 3*time.Hour),
+			},
+			expectedResult: created.Add(2 * time.Hour),
+		},
+		{
+			name:   "case 3 issue labeled by somebody else before the first comment",
+			author: "alice",
+			comments: []*github.IssueComment{
+				comment("bob", 2*time.Hour),
+			},
+			events: []*github.IssueEvent{
+				event("carol", "labeled", time.Hour),
+			},
+			expectedResult: created.Add(time.Hour),
+		},
+		{
+			name:   "case 4 issue assigned by somebody else",
+			author: "alice",
+			events: []*github.IssueEvent{
+				event("bob", "assigned", 30*time.Minute),
+			},
+			expectedResult: created.Add(30 * time.Minute),
+		},
+		{
+			name:   "case 5 issue labeled by the author and referenced by somebody else",
+			author: "alice",
+			events: []*github.IssueEvent{
+				event("alice", "labeled", time.Minute),
+				event("bob", "referenced", time.Hour),
+				event("bob", "subscribed", time.Hour),
+			},
+			expectedResult: time.Time{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := firstResponse(tc.author, tc.comments, tc.events)
+
+			if !result.Equal(tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -1,26 +1,33 @@
 package collector
 
 import (
+	"context"
+	"io/ioutil"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/giantswarm/to"
+	"github.com/giantswarm/micrologger"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-github/github"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/giantswarm/github-exporter/service/store/memory"
 )
 
 func Test_Collector_Issue_hasLabels(t *testing.T) {
 	testCases := []struct {
 		name           string
-		issue          *github.Issue
+		issue          issueRecord
 		selector       string
 		expectedResult bool
 	}{
 		{
 			name: "case 0 issue labels do not match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
 				},
 			},
 			selector:       "two",
@@ -28,11 +35,11 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 1 issue labels do not match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "four",
@@ -40,11 +47,11 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 2 issue labels do match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "one",
@@ -52,11 +59,11 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 3 issue labels do match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "two",
@@ -64,11 +71,11 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 4 issue labels do match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "one,two",
@@ -76,11 +83,11 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 5 issue labels do match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "two,one",
@@ -88,21 +95,130 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		},
 		{
 			name: "case 6 issue labels do not match selector",
-			issue: &github.Issue{
-				Labels: []github.Label{
-					{Name: to.StringP("one")},
-					{Name: to.StringP("two")},
-					{Name: to.StringP("three")},
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+					"two",
+					"three",
 				},
 			},
 			selector:       "one,four",
 			expectedResult: false,
 		},
+		{
+			name: "case 7 issue labels do match selector with OR",
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+				},
+			},
+			selector:       "two OR one",
+			expectedResult: true,
+		},
+		{
+			name: "case 8 issue labels do not match selector with OR",
+			issue: issueRecord{
+				Labels: []string{
+					"one",
+				},
+			},
+			selector:       "two OR three",
+			expectedResult: false,
+		},
+		{
+			name: "case 9 issue labels do match selector with NOT",
+			issue: issueRecord{
+				Labels: []string{
+					"kind/bug",
+				},
+			},
+			selector:       "kind/bug AND NOT wontfix",
+			expectedResult: true,
+		},
+		{
+			name: "case 10 issue labels do not match selector with NOT",
+			issue: issueRecord{
+				Labels: []string{
+					"kind/bug",
+					"wontfix",
+				},
+			},
+			selector:       "kind/bug AND NOT wontfix",
+			expectedResult: false,
+		},
+		{
+			name: "case 11 issue labels do match selector with glob",
+			issue: issueRecord{
+				Labels: []string{
+					"postmortem",
+					"team/batman",
+				},
+			},
+			selector:       "postmortem AND team/*",
+			expectedResult: true,
+		},
+		{
+			name: "case 12 issue labels do not match selector with glob",
+			issue: issueRecord{
+				Labels: []string{
+					"postmortem",
+					"area/team",
+				},
+			},
+			selector:       "postmortem AND team/*",
+			expectedResult: false,
+		},
+		{
+			name: "case 13 issue labels do match selector with parentheses",
+			issue: issueRecord{
+				Labels: []string{
+					"kind/bug",
+					"priority/p2",
+				},
+			},
+			selector:       "kind/bug AND (priority/p1 OR priority/p2)",
+			expectedResult: true,
+		},
+		{
+			name: "case 14 issue labels do not match selector with parentheses",
+			issue: issueRecord{
+				Labels: []string{
+					"priority/p2",
+				},
+			},
+			selector:       "kind/bug AND (priority/p1 OR priority/p2)",
+			expectedResult: false,
+		},
+		{
+			name: "case 15 issue labels do match selector with quoted label",
+			issue: issueRecord{
+				Labels: []string{
+					"good first issue",
+				},
+			},
+			selector:       `"good first issue" OR help`,
+			expectedResult: true,
+		},
+		{
+			name: "case 16 issue labels do match selector with single character wildcard",
+			issue: issueRecord{
+				Labels: []string{
+					"priority/p1",
+				},
+			},
+			selector:       "priority/p?",
+			expectedResult: true,
+		},
 	}
 
 	for i, tc := range testCases {
 		t.Run(strconv.Itoa(i), func(t *testing.T) {
-			result := hasLabels(tc.issue, tc.selector)
+			s, err := parseSelector(tc.selector)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			result := hasLabels(tc.issue, s)
 
 			if result != tc.expectedResult {
 				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
@@ -110,3 +226,473 @@ func Test_Collector_Issue_hasLabels(t *testing.T) {
 		})
 	}
 }
+
+func Test_Collector_Issue_parseSelector(t *testing.T) {
+	testCases := []struct {
+		name               string
+		selector           string
+		expectedNormalized string
+		errorMatcher       func(error) bool
+		expectedMessage    string
+	}{
+		{
+			name:               "case 0 comma separated labels stay unchanged",
+			selector:           "postmortem,team/batman",
+			expectedNormalized: "postmortem,team/batman",
+		},
+		{
+			name:               "case 1 AND is normalized to a comma",
+			selector:           "postmortem  AND team/*",
+			expectedNormalized: "postmortem,team/*",
+		},
+		{
+			name:               "case 2 redundant parentheses are removed",
+			selector:           "(kind/bug AND (NOT wontfix))",
+			expectedNormalized: "kind/bug,NOT wontfix",
+		},
+		{
+			name:               "case 3 parentheses are kept where needed",
+			selector:           "kind/bug AND (priority/p1 OR priority/p2)",
+			expectedNormalized: "kind/bug,(priority/p1 OR priority/p2)",
+		},
+		{
+			name:               "case 4 nested operators are flattened",
+			selector:           "a OR (b OR c) OR d AND (e AND f)",
+			expectedNormalized: "a OR b OR c OR d,e,f",
+		},
+		{
+			name:               "case 5 NOT of an expression keeps its parentheses",
+			selector:           "NOT (a OR b)",
+			expectedNormalized: "NOT (a OR b)",
+		},
+		{
+			name:               "case 6 quoted labels stay quoted where needed",
+			selector:           `"good first issue" OR "help"`,
+			expectedNormalized: `good first issue OR help`,
+		},
+		{
+			name:            "case 7 missing label",
+			selector:        "kind/bug AND",
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "expected label, `NOT` or `(` but got end of selector at position 13",
+		},
+		{
+			name:            "case 8 missing closing parenthesis",
+			selector:        "(a OR b",
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "expected `)` to close `(` at position 1 but got end of selector at position 8",
+		},
+		{
+			name:            "case 9 unexpected closing parenthesis",
+			selector:        "a OR b)",
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "unexpected `)` at position 7",
+		},
+		{
+			name:            "case 10 missing operator",
+			selector:        `a "b"`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "unexpected label `b` at position 3",
+		},
+		{
+			name:            "case 11 unterminated quote",
+			selector:        `a OR "b`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "unterminated quote at position 6",
+		},
+		{
+			name:            "case 12 empty selector",
+			selector:        "",
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "expected label, `NOT` or `(` but got end of selector at position 1",
+		},
+		{
+			name:               "case 13 consecutive words form a single label",
+			selector:           "good first issue AND NOT area: kaas",
+			expectedNormalized: "good first issue,NOT area: kaas",
+		},
+		{
+			name:               "case 14 multi-word labels containing keywords stay quoted",
+			selector:           `"help OR support" OR "question  asked"`,
+			expectedNormalized: `"help OR support" OR "question  asked"`,
+		},
+		{
+			name:               "case 15 whitespace within multi-word labels is kept",
+			selector:           "(question  asked)",
+			expectedNormalized: `"question  asked"`,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			s, err := parseSelector(tc.selector)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Fatalf("expected error message to contain %#q got %#q", tc.expectedMessage, err.Error())
+				}
+				return
+			}
+
+			if s.String() != tc.expectedNormalized {
+				t.Fatalf("\n\n%s\n", cmp.Diff(s.String(), tc.expectedNormalized))
+			}
+
+			// The normalized form must select the same issues, so it has to be
+			// parsed to itself.
+			n, err := parseSelector(s.String())
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			if n.String() != tc.expectedNormalized {
+				t.Fatalf("\n\n%s\n", cmp.Diff(n.String(), tc.expectedNormalized))
+			}
+		})
+	}
+}
+
+func Test_Collector_Issue_dimensionValues(t *testing.T) {
+	testCases := []struct {
+		name           string
+		labels         []string
+		dimensions     []string
+		expectedValues [][]string
+	}{
+		{
+			name: "case 0 labels of all dimensions",
+			labels: []string{
+				"kind/bug",
+				"team/batman",
+			},
+			dimensions: []string{
+				"team",
+				"kind",
+			},
+			expectedValues: [][]string{
+				{"batman", "bug"},
+			},
+		},
+		{
+			name: "case 1 missing dimension",
+			labels: []string{
+				"kind/bug",
+				"postmortem",
+			},
+			dimensions: []string{
+				"team",
+				"kind",
+			},
+			expectedValues: [][]string{
+				{"none", "bug"},
+			},
+		},
+		{
+			name: "case 2 multiple values of a dimension",
+			labels: []string{
+				"team/magic",
+				"team/batman",
+				"kind/bug",
+			},
+			dimensions: []string{
+				"team",
+				"kind",
+			},
+			expectedValues: [][]string{
+				{"batman", "bug"},
+				{"magic", "bug"},
+			},
+		},
+		{
+			name: "case 3 prefix without slash is not a dimension",
+			labels: []string{
+				"teamwork",
+				"team/",
+			},
+			dimensions: []string{
+				"team",
+			},
+			expectedValues: [][]string{
+				{"none"},
+			},
+		},
+		{
+			name:   "case 4 no labels",
+			labels: nil,
+			dimensions: []string{
+				"team",
+				"kind",
+			},
+			expectedValues: [][]string{
+				{"none", "none"},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			values := dimensionValues(tc.labels, tc.dimensions)
+
+			if !cmp.Equal(values, tc.expectedValues) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(values, tc.expectedValues))
+			}
+		})
+	}
+}
+
+func Test_Collector_Issue_issueMetrics_stale(t *testing.T) {
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	testCases := []struct {
+		name                string
+		issues              map[int]issueRecord
+		expectedStale       map[string]float64
+		expectedLabelsStale map[string]float64
+	}{
+		{
+			name: "case 0 recently updated issue is not stale",
+			issues: map[int]issueRecord{
+				1: {
+					Labels:    []string{"team/batman"},
+					State:     "open",
+					UpdatedAt: now.Add(-10 * day),
+				},
+			},
+			expectedStale:       map[string]float64{},
+			expectedLabelsStale: map[string]float64{},
+		},
+		{
+			name: "case 1 issues are counted for every exceeded threshold",
+			issues: map[int]issueRecord{
+				1: {
+					Labels:    []string{"team/batman"},
+					State:     "open",
+					UpdatedAt: now.Add(-100 * day),
+				},
+				2: {
+					State:     "open",
+					UpdatedAt: now.Add(-40 * day),
+				},
+			},
+			expectedStale: map[string]float64{
+				"30": 2,
+				"90": 1,
+			},
+			expectedLabelsStale: map[string]float64{
+				"team/batman/30": 1,
+				"team/batman/90": 1,
+			},
+		},
+		{
+			name: "case 2 closed issues are never stale",
+			issues: map[int]issueRecord{
+				1: {
+					Labels:    []string{"team/batman"},
+					State:     "closed",
+					UpdatedAt: now.Add(-200 * day),
+				},
+			},
+			expectedStale:       map[string]float64{},
+			expectedLabelsStale: map[string]float64{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := &Issue{
+				lifetimeBuckets: defaultLifetimeBuckets,
+				staleThresholds: []time.Duration{30 * day, 90 * day, 180 * day},
+			}
+
+			stale := map[string]float64{}
+			labelsStale := map[string]float64{}
+			for _, m := range c.issueMetrics(Repository{Org: "giantswarm", Name: "test"}, tc.issues, now) {
+				var d dto.Metric
+				err := m.Write(&d)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				labels := map[string]string{}
+				for _, l := range d.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				switch m.Desc() {
+				case issueStaleDesc:
+					stale[labels[labelDays]] = d.GetGauge().GetValue()
+				case issueLabelsStaleDesc:
+					labelsStale[labels[labelLabels]+"/"+labels[labelDays]] = d.GetGauge().GetValue()
+				}
+			}
+
+			if !cmp.Equal(stale, tc.expectedStale) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(stale, tc.expectedStale))
+			}
+			if !cmp.Equal(labelsStale, tc.expectedLabelsStale) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(labelsStale, tc.expectedLabelsStale))
+			}
+		})
+	}
+}
+
+func Test_Collector_Issue_NewIssue_dimensions(t *testing.T) {
+	testCases := []struct {
+		name         string
+		dimensions   []string
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0 valid dimensions",
+			dimensions:   []string{"area", "kind", "_team"},
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1 invalid label name",
+			dimensions:   []string{"kind/bug"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 2 reserved label name",
+			dimensions:   []string{"state"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 3 duplicate label name",
+			dimensions:   []string{"kind", "kind"},
+			errorMatcher: IsInvalidConfig,
+		},
+		{
+			name:         "case 4 label name reserved for internal use",
+			dimensions:   []string{"__kind"},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			s, err := memory.New(memory.Config{})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			c := IssueConfig{
+				Discovery:    &Discovery{},
+				GithubClient: github.NewClient(nil),
+				Logger:       logger,
+				Store:        s,
+
+				Dimensions: tc.dimensions,
+			}
+
+			_, err = NewIssue(c)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
+
+func Test_Collector_Issue_loadSync(t *testing.T) {
+	cursor := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+	issues := map[int]issueRecord{
+		1: {Number: 1, State: "open"},
+	}
+
+	testCases := []struct {
+		name           string
+		stored         interface{}
+		expectedResult *issueSync
+	}{
+		{
+			name:   "case 0 nothing stored",
+			stored: nil,
+			expectedResult: &issueSync{
+				Issues:  map[int]issueRecord{},
+				Version: issueSyncVersion,
+			},
+		},
+		{
+			name: "case 1 sync of the current version is loaded",
+			stored: &issueSync{
+				Cursor:  cursor,
+				Issues:  issues,
+				Version: issueSyncVersion,
+			},
+			expectedResult: &issueSync{
+				Cursor:  cursor,
+				Issues:  issues,
+				Version: issueSyncVersion,
+			},
+		},
+		{
+			name: "case 2 sync stored before versioning is discarded",
+			stored: map[string]interface{}{
+				"Cursor": cursor,
+				"Issues": issues,
+			},
+			expectedResult: &issueSync{
+				Issues:  map[int]issueRecord{},
+				Version: issueSyncVersion,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			s, err := memory.New(memory.Config{})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			r := Repository{Org: "giantswarm", Name: "github-exporter"}
+			if tc.stored != nil {
+				err = s.Put(issueStoreKey(r), tc.stored)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			issue := &Issue{
+				logger: logger,
+				store:  s,
+			}
+
+			result, err := issue.loadSync(context.Background(), r)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			if !cmp.Equal(result, tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	milestoneIssuesDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemMilestone, "issues_count"),
+		"Github issues per milestone.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelMilestone,
+			labelState,
+		},
+		nil,
+	)
+	milestoneCompletionDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemMilestone, "completion_ratio"),
+		"Ratio of closed Github issues of a milestone.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelMilestone,
+		},
+		nil,
+	)
+	milestoneDueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemMilestone, "due_timestamp_seconds"),
+		"Due date of a Github milestone as unix timestamp.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelMilestone,
+		},
+		nil,
+	)
+	milestoneOverdueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemMilestone, "overdue"),
+		"Whether a Github milestone is still open after its due date.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelMilestone,
+		},
+		nil,
+	)
+)
+
+const (
+	milestoneStateAll    = "all"
+	milestoneStateClosed = "closed"
+	milestoneStateOpen   = "open"
+)
+
+type MilestoneConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+
+	// State selects the milestones to collect. It is either open, closed or
+	// all. Defaults to open.
+	State string
+}
+
+type Milestone struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+
+	snapshot *snapshot
+
+	state string
+}
+
+func NewMilestone(config MilestoneConfig) (*Milestone, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.State == "" {
+		config.State = milestoneStateOpen
+	}
+	if config.State != milestoneStateAll && config.State != milestoneStateClosed && config.State != milestoneStateOpen {
+		return nil, microerror.Maskf(invalidConfigError, "%T.State must be one of %#q, %#q or %#q", config, milestoneStateOpen, milestoneStateClosed, milestoneStateAll)
+	}
+
+	m := &Milestone{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+
+		snapshot: newSnapshot(),
+
+		state: config.State,
+	}
+
+	return m, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (m *Milestone) Collect(ch chan<- prometheus.Metric) error {
+	m.snapshot.Collect(ch)
+	return nil
+}
+
+func (m *Milestone) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- milestoneIssuesDesc
+	ch <- milestoneCompletionDesc
+	ch <- milestoneDueDesc
+	ch <- milestoneOverdueDesc
+	return nil
+}
+
+// Refresh fetches the milestones of all discovered repositories and replaces
+// the snapshot emitted by Collect.
+func (m *Milestone) Refresh(ctx context.Context) error {
+	err := m.snapshot.Refresh(ctx, m.logger, m.discovery.Repositories(), m.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (m *Milestone) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	opts := &github.MilestoneListOptions{
+		ListOptions: github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		},
+		State: m.state,
+	}
+
+	var metrics []prometheus.Metric
+
+	for {
+		milestones, res, err := m.githubClient.Issues.ListMilestones(ctx, r.Org, r.Name, opts)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		m.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d milestones of page %2d for repository %#q", len(milestones), opts.Page, r.String()))
+
+		for _, milestone := range milestones {
+			metrics = append(metrics, milestoneMetrics(r, milestone, now)...)
+		}
+
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return metrics, nil
+}
+
+func milestoneMetrics(r Repository, milestone *github.Milestone, now time.Time) []prometheus.Metric {
+	title := milestone.GetTitle()
+	open := float64(milestone.GetOpenIssues())
+	closed := float64(milestone.GetClosedIssues())
+
+	metrics := []prometheus.Metric{
+		prometheus.MustNewConstMetric(
+			milestoneIssuesDesc,
+			prometheus.GaugeValue,
+			open,
+			r.Org,
+			r.Name,
+			title,
+			milestoneStateOpen,
+		),
+		prometheus.MustNewConstMetric(
+			milestoneIssuesDesc,
+			prometheus.GaugeValue,
+			closed,
+			r.Org,
+			r.Name,
+			title,
+			milestoneStateClosed,
+		),
+		prometheus.MustNewConstMetric(
+			milestoneCompletionDesc,
+			prometheus.GaugeValue,
+			milestoneCompletion(open, closed),
+			r.Org,
+			r.Name,
+			title,
+		),
+	}
+
+	// Milestones without due date neither have a due timestamp nor can they be
+	// overdue.
+	if milestone.DueOn != nil {
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			milestoneDueDesc,
+			prometheus.GaugeValue,
+			float64(milestone.GetDueOn().Unix()),
+			r.Org,
+			r.Name,
+			title,
+		))
+
+		var overdue float64
+		if isMilestoneOverdue(milestone.GetState(), milestone.GetDueOn(), now) {
+			overdue = 1
+		}
+		metrics = append(metrics, prometheus.MustNewConstMetric(
+			milestoneOverdueDesc,
+			prometheus.GaugeValue,
+			overdue,
+			r.Org,
+			r.Name,
+			title,
+		))
+	}
+
+	return metrics
+}
+
+// milestoneCompletion returns the ratio of closed issues. Milestones without
+// issues are not completed.
+func milestoneCompletion(open float64, closed float64) float64 {
+	if open+closed == 0 {
+		return 0
+	}
+
+	return closed / (open + closed)
+}
+
+func isMilestoneOverdue(state string, dueOn time.Time, now time.Time) bool {
+	return state == milestoneStateOpen && dueOn.Before(now)
+}
@@ -0,0 +1,93 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Collector_Milestone_milestoneCompletion(t *testing.T) {
+	testCases := []struct {
+		name           string
+		open           float64
+		closed         float64
+		expectedResult float64
+	}{
+		{
+			name:           "case 0 milestone without issues",
+			open:           0,
+			closed:         0,
+			expectedResult: 0,
+		},
+		{
+			name:           "case 1 milestone with open issues only",
+			open:           3,
+			closed:         0,
+			expectedResult: 0,
+		},
+		{
+			name:           "case 2 milestone with open and closed issues",
+			open:           1,
+			closed:         3,
+			expectedResult: 0.75,
+		},
+		{
+			name:           "case 3 milestone with closed issues only",
+			open:           0,
+			closed:         5,
+			expectedResult: 1,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := milestoneCompletion(tc.open, tc.closed)
+
+			if result != tc.expectedResult {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
+
+func Test_Collector_Milestone_isMilestoneOverdue(t *testing.T) {
+	now := time.Date(2019, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		state          string
+		dueOn          time.Time
+		expectedResult bool
+	}{
+		{
+			name:           "case 0 open milestone due in the future",
+			state:          "open",
+			dueOn:          now.Add(24 * time.Hour),
+			expectedResult: false,
+		},
+		{
+			name:           "case 1 open milestone due in the past",
+			state:          "open",
+			dueOn:          now.Add(-24 * time.Hour),
+			expectedResult: true,
+		},
+		{
+			name:           "case 2 closed milestone due in the past",
+			state:          "closed",
+			dueOn:          now.Add(-24 * time.Hour),
+			expectedResult: false,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := isMilestoneOverdue(tc.state, tc.dueOn, now)
+
+			if result != tc.expectedResult {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	labelCollector = "collector"
+)
+
+var (
+	collectorDurationDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "duration_seconds"),
+		"Duration of the last refresh of a collector's data.",
+		[]string{
+			labelCollector,
+		},
+		nil,
+	)
+	collectorSuccessDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "collector", "success"),
+		"Whether the last refresh of a collector's data succeeded.",
+		[]string{
+			labelCollector,
+		},
+		nil,
+	)
+	lastSuccessfulRefreshDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "last_successful_refresh_timestamp_seconds"),
+		"Unix timestamp of the last successful refresh of a collector's data.",
+		[]string{
+			labelCollector,
+		},
+		nil,
+	)
+)
+
+// Refresher is implemented by collectors which fetch their data from the
+// Github API in the background. Their Collect implementation only emits the
+// snapshot of the latest refresh, so that Prometheus scrapes are cheap and do
+// not consume any API quota.
+type Refresher interface {
+	Refresh(ctx context.Context) error
+}
+
+type PollerConfig struct {
+	Logger micrologger.Logger
+	// Refreshers maps collector names to their refresher implementations. The
+	// collector name is used as label value of the poller's metrics.
+	Refreshers map[string]Refresher
+
+	Interval time.Duration
+}
+
+// Poller periodically refreshes the snapshots of all given refreshers.
+type Poller struct {
+	logger     micrologger.Logger
+	refreshers map[string]Refresher
+
+	bootOnce              sync.Once
+	lastRefreshDuration   map[string]time.Duration
+	lastRefreshSuccess    map[string]bool
+	lastSuccessfulRefresh map[string]time.Time
+	mutex                 sync.RWMutex
+
+	interval time.Duration
+}
+
+func NewPoller(config PollerConfig) (*Poller, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if len(config.Refreshers) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Refreshers must not be empty", config)
+	}
+
+	if config.Interval <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Interval must be greater than 0", config)
+	}
+
+	p := &Poller{
+		logger:     config.Logger,
+		refreshers: config.Refreshers,
+
+		bootOnce:              sync.Once{},
+		lastRefreshDuration:   map[string]time.Duration{},
+		lastRefreshSuccess:    map[string]bool{},
+		lastSuccessfulRefresh: map[string]time.Time{},
+		mutex:                 sync.RWMutex{},
+
+		interval: config.Interval,
+	}
+
+	return p, nil
+}
+
+// Boot refreshes all snapshots once and then keeps refreshing them in the
+// background in the configured interval until the given context is done.
+func (p *Poller) Boot(ctx context.Context) {
+	p.bootOnce.Do(func() {
+		p.refresh(ctx)
+
+		go func() {
+			ticker := time.NewTicker(p.interval)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					p.refresh(ctx)
+				}
+			}
+		}()
+	})
+}
+
+func (p *Poller) Collect(ch chan<- prometheus.Metric) error {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	for name, d := range p.lastRefreshDuration {
+		ch <- prometheus.MustNewConstMetric(
+			collectorDurationDesc,
+			prometheus.GaugeValue,
+			d.Seconds(),
+			name,
+		)
+	}
+
+	for name, ok := range p.lastRefreshSuccess {
+		var v float64
+		if ok {
+			v = 1
+		}
+
+		ch <- prometheus.MustNewConstMetric(
+			collectorSuccessDesc,
+			prometheus.GaugeValue,
+			v,
+			name,
+		)
+	}
+
+	for name, t := range p.lastSuccessfulRefresh {
+		ch <- prometheus.MustNewConstMetric(
+			lastSuccessfulRefreshDesc,
+			prometheus.GaugeValue,
+			float64(t.Unix()),
+			name,
+		)
+	}
+
+	return nil
+}
+
+func (p *Poller) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- collectorDurationDesc
+	ch <- collectorSuccessDesc
+	ch <- lastSuccessfulRefreshDesc
+	return nil
+}
+
+func (p *Poller) refresh(ctx context.Context) {
+	var names []string
+	for name := range p.refreshers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("refreshing collector %#q", name))
+
+		start := time.Now()
+		err := p.refreshers[name].Refresh(ctx)
+		end := time.Now()
+
+		p.mutex.Lock()
+		p.lastRefreshDuration[name] = end.Sub(start)
+		p.lastRefreshSuccess[name] = err == nil
+		if err == nil {
+			p.lastSuccessfulRefresh[name] = end
+		}
+		p.mutex.Unlock()
+
+		if err != nil {
+			p.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed refreshing collector %#q", name), "stack", fmt.Sprintf("%#v", err))
+			continue
+		}
+
+		p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("refreshed collector %#q", name))
+	}
+}
@@ -0,0 +1,594 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/github-exporter/service/store"
+)
+
+var (
+	pullRequestLabelsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "labels_count"),
+		"Github pull requests per labels.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelLabels,
+			labelState,
+		},
+		nil,
+	)
+	pullRequestStatesDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "states_count"),
+		"Github pull request states.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelState,
+		},
+		nil,
+	)
+	pullRequestDraftsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "drafts_count"),
+		"Open Github draft pull requests.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	pullRequestReviewsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "reviews_count"),
+		"Github pull request reviews per review state.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelState,
+		},
+		nil,
+	)
+	pullRequestTimeToMergeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "time_to_merge"),
+		"Time between creating and merging Github pull requests.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	pullRequestTimeToFirstReviewDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemPullRequest, "time_to_first_review"),
+		"Time between creating Github pull requests and their first review by somebody other than the author.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+)
+
+var (
+	// defaultPullRequestBuckets ranges from one hour to 2048 hours, which is
+	// roughly 85 days.
+	defaultPullRequestBuckets = prometheus.ExponentialBuckets(60*60, 2, 12)
+)
+
+const (
+	pullRequestStateClosed = "closed"
+	pullRequestStateMerged = "merged"
+	pullRequestStateOpen   = "open"
+)
+
+// pullRequest extends github.PullRequest by the draft flag, which is not
+// supported by the vendored go-github version.
+type pullRequest struct {
+	github.PullRequest
+
+	Draft *bool `json:"draft,omitempty"`
+}
+
+// pullRequestRecord is the compact representation of a Github pull request
+// and the summary of its reviews kept between refreshes.
+type pullRequestRecord struct {
+	Author    string
+	ClosedAt  time.Time
+	CreatedAt time.Time
+	Draft     bool
+	Labels    []string
+	MergedAt  time.Time
+	Number    int
+	// State is open, closed or merged. See pullRequestState.
+	State     string
+	UpdatedAt time.Time
+
+	Reviews pullRequestReviews
+}
+
+// pullRequestReviews summarizes the reviews of a single pull request. Reviews
+// are only fetched again when the pull request was updated.
+type pullRequestReviews struct {
+	FirstReviewAt time.Time
+	States        map[string]float64
+}
+
+// pullRequestSync holds all pull requests of a repository known so far and
+// the cursor of the last successful sync. Only pull requests updated since the
+// cursor are fetched on the next refresh.
+type pullRequestSync struct {
+	Cursor       time.Time
+	PullRequests map[int]pullRequestRecord
+}
+
+type PullRequestConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+	// Store persists the pull requests of each repository and the cursor of
+	// their last sync, so that a restart does not cause a full sync.
+	Store store.Interface
+
+	// Buckets are the upper bounds in seconds of the buckets of the time to
+	// merge and time to first review histograms. Defaults to exponential
+	// buckets ranging from one hour to 2048 hours.
+	Buckets []float64
+	// Retention is the time after which closed and merged pull requests are
+	// dropped. It also limits the initial sync to pull requests updated within
+	// the retention. Zero keeps all pull requests.
+	Retention time.Duration
+}
+
+type PullRequest struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+	store        store.Interface
+
+	pullRequests map[Repository]*pullRequestSync
+	snapshot     *snapshot
+
+	buckets   []float64
+	retention time.Duration
+}
+
+func NewPullRequest(config PullRequestConfig) (*PullRequest, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	if len(config.Buckets) == 0 {
+		config.Buckets = defaultPullRequestBuckets
+	}
+	if !sort.Float64sAreSorted(config.Buckets) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Buckets must be in increasing order", config)
+	}
+	if config.Retention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Retention must not be negative", config)
+	}
+
+	p := &PullRequest{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+		store:        config.Store,
+
+		pullRequests: map[Repository]*pullRequestSync{},
+		snapshot:     newSnapshot(),
+
+		buckets:   config.Buckets,
+		retention: config.Retention,
+	}
+
+	return p, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (p *PullRequest) Collect(ch chan<- prometheus.Metric) error {
+	p.snapshot.Collect(ch)
+	return nil
+}
+
+func (p *PullRequest) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- pullRequestLabelsDesc
+	ch <- pullRequestStatesDesc
+	ch <- pullRequestDraftsDesc
+	ch <- pullRequestReviewsDesc
+	ch <- pullRequestTimeToMergeDesc
+	ch <- pullRequestTimeToFirstReviewDesc
+	return nil
+}
+
+// Refresh syncs the pull requests of all discovered repositories and replaces
+// the snapshot emitted by Collect.
+func (p *PullRequest) Refresh(ctx context.Context) error {
+	repositories := p.discovery.Repositories()
+
+	// Repositories which are not discovered anymore do not need to be synced
+	// anymore.
+	for r := range p.pullRequests {
+		if !containsRepository(repositories, r) {
+			delete(p.pullRequests, r)
+		}
+	}
+
+	err := p.snapshot.Refresh(ctx, p.logger, repositories, p.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (p *PullRequest) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	synced, ok := p.pullRequests[r]
+	if !ok {
+		var err error
+		synced, err = p.loadSync(ctx, r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	since := synced.Cursor
+	if since.IsZero() && p.retention != 0 {
+		since = now.Add(-p.retention)
+	}
+
+	updated, cursor, err := p.listPullRequests(ctx, r, "all", since)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Open pull requests are counted regardless of the retention. So the
+	// initial sync also lists the open pull requests which were not updated
+	// within the retention.
+	if synced.Cursor.IsZero() && !since.IsZero() {
+		open, openCursor, err := p.listPullRequests(ctx, r, "open", time.Time{})
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		updated = append(open, updated...)
+		if openCursor.After(cursor) {
+			cursor = openCursor
+		}
+	}
+
+	// Reviews are fetched before merging the updated pull requests, so that a
+	// failing request does not leave the sync half updated.
+	for n, record := range updated {
+		previous, ok := synced.PullRequests[record.Number]
+		if ok && previous.UpdatedAt.Equal(record.UpdatedAt) {
+			updated[n].Reviews = previous.Reviews
+			continue
+		}
+
+		reviews, err := p.pullRequestReviews(ctx, r, record)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+		updated[n].Reviews = reviews
+	}
+
+	for _, record := range updated {
+		synced.PullRequests[record.Number] = record
+	}
+	if cursor.After(synced.Cursor) {
+		synced.Cursor = cursor
+	}
+
+	if p.retention != 0 {
+		for n, record := range synced.PullRequests {
+			if record.State != pullRequestStateOpen && record.ClosedAt.Before(now.Add(-p.retention)) {
+				delete(synced.PullRequests, n)
+			}
+		}
+	}
+
+	p.pullRequests[r] = synced
+
+	// Failing to persist the sync only means that the next restart has to sync
+	// more pull requests, so the refresh does not fail because of it.
+	err = p.store.Put(pullRequestStoreKey(r), synced)
+	if err != nil {
+		p.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed storing pull requests of repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	counts := newPullRequestCounts(p.buckets)
+	for _, record := range synced.PullRequests {
+		counts.Add(record)
+	}
+
+	return counts.Metrics(r), nil
+}
+
+// loadSync returns the sync of the given repository persisted by a previous
+// run of the exporter, or an empty sync if there is none.
+func (p *PullRequest) loadSync(ctx context.Context, r Repository) (*pullRequestSync, error) {
+	synced := &pullRequestSync{}
+
+	ok, err := p.store.Get(pullRequestStoreKey(r), synced)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if ok {
+		p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("loaded %d stored pull requests for repository %#q", len(synced.PullRequests), r.String()))
+	}
+	if synced.PullRequests == nil {
+		synced.PullRequests = map[int]pullRequestRecord{}
+	}
+
+	return synced, nil
+}
+
+// listPullRequests returns the pull requests of the given repository in the
+// given state updated since the given time, together with the most recent
+// update time seen. Their reviews are left empty. A zero since lists all pull
+// requests.
+func (p *PullRequest) listPullRequests(ctx context.Context, r Repository, state string, since time.Time) ([]pullRequestRecord, time.Time, error) {
+	var cursor time.Time
+	var records []pullRequestRecord
+
+	page := 1
+	for {
+		// Pull requests cannot be filtered by their update time. We list them
+		// ordered by their update time instead and stop as soon as we reach pull
+		// requests updated before the given time.
+		u := fmt.Sprintf("repos/%s/%s/pulls?direction=desc&page=%d&per_page=100&sort=updated&state=%s", r.Org, r.Name, page, state)
+		req, err := p.githubClient.NewRequest("GET", u, nil)
+		if err != nil {
+			return nil, time.Time{}, microerror.Mask(err)
+		}
+
+		var pullRequests []*pullRequest
+		res, err := p.githubClient.Do(ctx, req, &pullRequests)
+		if err != nil {
+			return nil, time.Time{}, microerror.Mask(err)
+		}
+
+		p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d pull requests of page %2d for repository %#q", len(pullRequests), page, r.String()))
+
+		var done bool
+		for _, pr := range pullRequests {
+			if pr.GetUpdatedAt().Before(since) {
+				done = true
+				break
+			}
+
+			// The cursor is based on the update times given by Github, so that it
+			// does not depend on the exporter's clock.
+			if pr.GetUpdatedAt().After(cursor) {
+				cursor = pr.GetUpdatedAt()
+			}
+
+			records = append(records, newPullRequestRecord(pr))
+		}
+
+		if done || res.NextPage == 0 {
+			p.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collected all pull requests for repository %#q", r.String()))
+			break
+		}
+		page = res.NextPage
+	}
+
+	return records, cursor, nil
+}
+
+// pullRequestReviews fetches the reviews of the given pull request and returns
+// their summary.
+func (p *PullRequest) pullRequestReviews(ctx context.Context, r Repository, record pullRequestRecord) (pullRequestReviews, error) {
+	var reviews []*github.PullRequestReview
+	{
+		opts := &github.ListOptions{
+			Page:    1,
+			PerPage: 100,
+		}
+
+		for {
+			list, res, err := p.githubClient.PullRequests.ListReviews(ctx, r.Org, r.Name, record.Number, opts)
+			if err != nil {
+				return pullRequestReviews{}, microerror.Mask(err)
+			}
+			reviews = append(reviews, list...)
+
+			if res.NextPage == 0 {
+				break
+			}
+			opts.Page = res.NextPage
+		}
+	}
+
+	return summarizeReviews(record.Author, reviews), nil
+}
+
+// pullRequestCounts accumulates the metrics of the pull requests of a single
+// repository.
+type pullRequestCounts struct {
+	Drafts            float64
+	Labels            map[pullRequestLabel]float64
+	Reviews           map[string]float64
+	States            map[string]float64
+	TimeToFirstReview *histogram
+	TimeToMerge       *histogram
+}
+
+type pullRequestLabel struct {
+	Label string
+	State string
+}
+
+func newPullRequestCounts(buckets []float64) *pullRequestCounts {
+	c := &pullRequestCounts{
+		Labels:            map[pullRequestLabel]float64{},
+		Reviews:           map[string]float64{},
+		States:            map[string]float64{},
+		TimeToFirstReview: newHistogram(buckets),
+		TimeToMerge:       newHistogram(buckets),
+	}
+
+	return c
+}
+
+// Add counts the given pull request and the summary of its reviews.
+func (c *pullRequestCounts) Add(record pullRequestRecord) {
+	if record.State == pullRequestStateMerged {
+		c.TimeToMerge.Observe(record.MergedAt.Sub(record.CreatedAt).Seconds())
+	}
+	if record.State == pullRequestStateOpen && record.Draft {
+		c.Drafts++
+	}
+
+	c.States[record.State]++
+	for _, label := range record.Labels {
+		k := pullRequestLabel{
+			Label: label,
+			State: record.State,
+		}
+		c.Labels[k]++
+	}
+
+	if !record.Reviews.FirstReviewAt.IsZero() {
+		c.TimeToFirstReview.Observe(record.Reviews.FirstReviewAt.Sub(record.CreatedAt).Seconds())
+	}
+	for s, v := range record.Reviews.States {
+		c.Reviews[s] += v
+	}
+}
+
+// Metrics returns the metrics of the counted pull requests of the given
+// repository.
+func (c *pullRequestCounts) Metrics(r Repository) []prometheus.Metric {
+	var metrics []prometheus.Metric
+
+	for k, v := range c.Labels {
+		m := prometheus.MustNewConstMetric(
+			pullRequestLabelsDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k.Label,
+			k.State,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for _, s := range []string{pullRequestStateClosed, pullRequestStateMerged, pullRequestStateOpen} {
+		m := prometheus.MustNewConstMetric(
+			pullRequestStatesDesc,
+			prometheus.GaugeValue,
+			c.States[s],
+			r.Org,
+			r.Name,
+			s,
+		)
+		metrics = append(metrics, m)
+	}
+
+	{
+		m := prometheus.MustNewConstMetric(
+			pullRequestDraftsDesc,
+			prometheus.GaugeValue,
+			c.Drafts,
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, v := range c.Reviews {
+		m := prometheus.MustNewConstMetric(
+			pullRequestReviewsDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k,
+		)
+		metrics = append(metrics, m)
+	}
+
+	metrics = append(metrics, c.TimeToMerge.Metric(pullRequestTimeToMergeDesc, r.Org, r.Name))
+	metrics = append(metrics, c.TimeToFirstReview.Metric(pullRequestTimeToFirstReviewDesc, r.Org, r.Name))
+
+	return metrics
+}
+
+func pullRequestStoreKey(r Repository) string {
+	return "pullrequest/" + r.String()
+}
+
+// newPullRequestRecord returns the record of the given pull request without
+// the summary of its reviews.
+func newPullRequestRecord(pr *pullRequest) pullRequestRecord {
+	record := pullRequestRecord{
+		Author:    pr.GetUser().GetLogin(),
+		ClosedAt:  pr.GetClosedAt(),
+		CreatedAt: pr.GetCreatedAt(),
+		Draft:     pr.Draft != nil && *pr.Draft,
+		MergedAt:  pr.GetMergedAt(),
+		Number:    pr.GetNumber(),
+		State:     pullRequestState(pr),
+		UpdatedAt: pr.GetUpdatedAt(),
+	}
+	for _, label := range pr.Labels {
+		record.Labels = append(record.Labels, label.GetName())
+	}
+
+	return record
+}
+
+// pullRequestState returns the state of the given pull request. Github reports
+// merged pull requests as closed, so they are told apart by their merge date.
+func pullRequestState(pr *pullRequest) string {
+	if pr.MergedAt != nil {
+		return pullRequestStateMerged
+	}
+
+	return pr.GetState()
+}
+
+// summarizeReviews returns the review summary of the given reviews of a pull
+// request opened by the given author.
+func summarizeReviews(author string, reviews []*github.PullRequestReview) pullRequestReviews {
+	summary := pullRequestReviews{
+		States: map[string]float64{},
+	}
+
+	for _, review := range reviews {
+		// Pending reviews are not submitted yet and authors commenting on their
+		// own pull requests do not count as review.
+		if review.GetState() == "PENDING" || review.GetUser().GetLogin() == author {
+			continue
+		}
+
+		summary.States[strings.ToLower(review.GetState())]++
+
+		submittedAt := review.GetSubmittedAt()
+		if summary.FirstReviewAt.IsZero() || submittedAt.Before(summary.FirstReviewAt) {
+			summary.FirstReviewAt = submittedAt
+		}
+	}
+
+	return summary
+}
@@ -0,0 +1,227 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func Test_Collector_PullRequest_pullRequestCounts(t *testing.T) {
+	created := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	newPullRequest := func(state string, mergedAfter time.Duration, draft bool, labels ...string) *pullRequest {
+		pr := &pullRequest{
+			PullRequest: github.PullRequest{
+				CreatedAt: &created,
+				State:     github.String(state),
+			},
+			Draft: github.Bool(draft),
+		}
+		if mergedAfter != 0 {
+			mergedAt := created.Add(mergedAfter)
+			pr.MergedAt = &mergedAt
+		}
+		for _, l := range labels {
+			pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l)})
+		}
+
+		return pr
+	}
+
+	testCases := []struct {
+		name                      string
+		pullRequests              []*pullRequest
+		summaries                 []pullRequestReviews
+		expectedDrafts            float64
+		expectedLabels            map[pullRequestLabel]float64
+		expectedReviews           map[string]float64
+		expectedStates            map[string]float64
+		expectedTimeToFirstReview []float64
+		expectedTimeToMerge       []float64
+	}{
+		{
+			name: "case 0 merged pull requests are told apart from closed ones",
+			pullRequests: []*pullRequest{
+				newPullRequest("closed", time.Hour, false, "kind/bug"),
+				newPullRequest("closed", 0, false, "kind/bug"),
+				newPullRequest("open", 0, false),
+			},
+			summaries:      []pullRequestReviews{{}, {}, {}},
+			expectedDrafts: 0,
+			expectedLabels: map[pullRequestLabel]float64{
+				{Label: "kind/bug", State: "closed"}: 1,
+				{Label: "kind/bug", State: "merged"}: 1,
+			},
+			expectedReviews: map[string]float64{},
+			expectedStates: map[string]float64{
+				"closed": 1,
+				"merged": 1,
+				"open":   1,
+			},
+			expectedTimeToFirstReview: []float64{0, 0},
+			expectedTimeToMerge:       []float64{1, 3600},
+		},
+		{
+			name: "case 1 drafts are only counted while open",
+			pullRequests: []*pullRequest{
+				newPullRequest("open", 0, true),
+				newPullRequest("open", 0, false),
+				newPullRequest("closed", 0, true),
+			},
+			summaries:       []pullRequestReviews{{}, {}, {}},
+			expectedDrafts:  1,
+			expectedLabels:  map[pullRequestLabel]float64{},
+			expectedReviews: map[string]float64{},
+			expectedStates: map[string]float64{
+				"closed": 1,
+				"open":   2,
+			},
+			expectedTimeToFirstReview: []float64{0, 0},
+			expectedTimeToMerge:       []float64{0, 0},
+		},
+		{
+			name: "case 2 review summaries are summed up",
+			pullRequests: []*pullRequest{
+				newPullRequest("open", 0, false),
+				newPullRequest("open", 0, false),
+			},
+			summaries: []pullRequestReviews{
+				{
+					FirstReviewAt: created.Add(2 * time.Hour),
+					States: map[string]float64{
+						"approved":  1,
+						"commented": 2,
+					},
+				},
+				{
+					States: map[string]float64{},
+				},
+			},
+			expectedDrafts: 0,
+			expectedLabels: map[pullRequestLabel]float64{},
+			expectedReviews: map[string]float64{
+				"approved":  1,
+				"commented": 2,
+			},
+			expectedStates: map[string]float64{
+				"open": 2,
+			},
+			expectedTimeToFirstReview: []float64{1, 7200},
+			expectedTimeToMerge:       []float64{0, 0},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := newPullRequestCounts(defaultPullRequestBuckets)
+			for j, pr := range tc.pullRequests {
+				record := newPullRequestRecord(pr)
+				record.Reviews = tc.summaries[j]
+				c.Add(record)
+			}
+
+			if c.Drafts != tc.expectedDrafts {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.Drafts, tc.expectedDrafts))
+			}
+			if !cmp.Equal(c.Labels, tc.expectedLabels) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.Labels, tc.expectedLabels))
+			}
+			if !cmp.Equal(c.Reviews, tc.expectedReviews) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.Reviews, tc.expectedReviews))
+			}
+			if !cmp.Equal(c.States, tc.expectedStates) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.States, tc.expectedStates))
+			}
+
+			timeToFirstReview := []float64{float64(c.TimeToFirstReview.count), c.TimeToFirstReview.sum}
+			if !cmp.Equal(timeToFirstReview, tc.expectedTimeToFirstReview) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(timeToFirstReview, tc.expectedTimeToFirstReview))
+			}
+			timeToMerge := []float64{float64(c.TimeToMerge.count), c.TimeToMerge.sum}
+			if !cmp.Equal(timeToMerge, tc.expectedTimeToMerge) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(timeToMerge, tc.expectedTimeToMerge))
+			}
+		})
+	}
+}
+
+func Test_Collector_PullRequest_summarizeReviews(t *testing.T) {
+	submitted := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	newReview := func(user string, state string, submittedAfter time.Duration) *github.PullRequestReview {
+		submittedAt := submitted.Add(submittedAfter)
+		r := &github.PullRequestReview{
+			State:       github.String(state),
+			SubmittedAt: &submittedAt,
+			User:        &github.User{Login: github.String(user)},
+		}
+
+		return r
+	}
+
+	testCases := []struct {
+		name           string
+		reviews        []*github.PullRequestReview
+		expectedResult pullRequestReviews
+	}{
+		{
+			name:    "case 0 no reviews",
+			reviews: nil,
+			expectedResult: pullRequestReviews{
+				States: map[string]float64{},
+			},
+		},
+		{
+			name: "case 1 reviews are counted per state and the first review is the earliest",
+			reviews: []*github.PullRequestReview{
+				newReview("reviewer1", "COMMENTED", 2*time.Hour),
+				newReview("reviewer2", "CHANGES_REQUESTED", time.Hour),
+				newReview("reviewer1", "APPROVED", 3*time.Hour),
+			},
+			expectedResult: pullRequestReviews{
+				FirstReviewAt: submitted.Add(time.Hour),
+				States: map[string]float64{
+					"approved":          1,
+					"changes_requested": 1,
+					"commented":         1,
+				},
+			},
+		},
+		{
+			name: "case 2 pending reviews and reviews of the author are ignored",
+			reviews: []*github.PullRequestReview{
+				newReview("reviewer1", "PENDING", 0),
+				newReview("author", "COMMENTED", time.Hour),
+				newReview("reviewer2", "APPROVED", 2*time.Hour),
+			},
+			expectedResult: pullRequestReviews{
+				FirstReviewAt: submitted.Add(2 * time.Hour),
+				States: map[string]float64{
+					"approved": 1,
+				},
+			},
+		},
+		{
+			name: "case 3 pull requests reviewed only by their author have no first review",
+			reviews: []*github.PullRequestReview{
+				newReview("author", "COMMENTED", time.Hour),
+			},
+			expectedResult: pullRequestReviews{
+				States: map[string]float64{},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := summarizeReviews("author", tc.reviews)
+
+			if !cmp.Equal(result, tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	releaseCountDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "count"),
+		"Published Github releases per type, which is either release or prerelease.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelType,
+		},
+		nil,
+	)
+	releaseLatestDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "latest_timestamp_seconds"),
+		"Publish date of the latest Github release per type as unix timestamp.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelType,
+		},
+		nil,
+	)
+	releaseDaysSinceLatestDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "days_since_latest"),
+		"Days since the latest Github release which is not a prerelease was published, as of the last refresh.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	releaseAssetDownloadsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRelease, "asset_downloads_total"),
+		"Downloads of the assets of recent Github releases.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelRelease,
+			labelAsset,
+		},
+		nil,
+	)
+)
+
+const (
+	releaseTypePrerelease = "prerelease"
+	releaseTypeRelease    = "release"
+)
+
+type ReleaseConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+
+	// AssetPattern is a regular expression matching the names of the assets
+	// whose downloads are exported. All assets are exported if empty.
+	AssetPattern string
+	// RecentReleases is the number of the most recently published releases
+	// whose asset downloads are exported. Defaults to 5.
+	RecentReleases int
+}
+
+type Release struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+
+	snapshot *snapshot
+
+	assetPattern   *regexp.Regexp
+	recentReleases int
+}
+
+func NewRelease(config ReleaseConfig) (*Release, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	assetPattern, err := regexp.Compile(config.AssetPattern)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AssetPattern must be a valid regular expression but got %#q: %s", config, config.AssetPattern, err)
+	}
+	if config.RecentReleases == 0 {
+		config.RecentReleases = 5
+	}
+	if config.RecentReleases < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.RecentReleases must not be negative", config)
+	}
+
+	r := &Release{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+
+		snapshot: newSnapshot(),
+
+		assetPattern:   assetPattern,
+		recentReleases: config.RecentReleases,
+	}
+
+	return r, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (c *Release) Collect(ch chan<- prometheus.Metric) error {
+	c.snapshot.Collect(ch)
+	return nil
+}
+
+func (c *Release) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- releaseCountDesc
+	ch <- releaseLatestDesc
+	ch <- releaseDaysSinceLatestDesc
+	ch <- releaseAssetDownloadsDesc
+	return nil
+}
+
+// Refresh fetches the releases of all discovered repositories and replaces the
+// snapshot emitted by Collect.
+func (c *Release) Refresh(ctx context.Context) error {
+	err := c.snapshot.Refresh(ctx, c.logger, c.discovery.Repositories(), c.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (c *Release) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	opts := &github.ListOptions{
+		Page:    1,
+		PerPage: 100,
+	}
+
+	var releases []*github.RepositoryRelease
+
+	for {
+		list, res, err := c.githubClient.Repositories.ListReleases(ctx, r.Org, r.Name, opts)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		c.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d releases of page %2d for repository %#q", len(list), opts.Page, r.String()))
+
+		releases = append(releases, list...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		opts.Page = res.NextPage
+	}
+
+	return c.releaseMetrics(r, releases, now), nil
+}
+
+func (c *Release) releaseMetrics(r Repository, releases []*github.RepositoryRelease, now time.Time) []prometheus.Metric {
+	// Drafts are only listed for users with push access and are not published
+	// yet, so they are ignored.
+	var published []*github.RepositoryRelease
+	for _, release := range releases {
+		if !release.GetDraft() {
+			published = append(published, release)
+		}
+	}
+
+	sort.SliceStable(published, func(a, b int) bool {
+		return published[a].GetPublishedAt().After(published[b].GetPublishedAt().Time)
+	})
+
+	counts := map[string]float64{
+		releaseTypePrerelease: 0,
+		releaseTypeRelease:    0,
+	}
+	latest := map[string]time.Time{}
+
+	for _, release := range published {
+		t := releaseTypeRelease
+		if release.GetPrerelease() {
+			t = releaseTypePrerelease
+		}
+
+		counts[t]++
+		if release.GetPublishedAt().After(latest[t]) {
+			latest[t] = release.GetPublishedAt().Time
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	for t, v := range counts {
+		m := prometheus.MustNewConstMetric(
+			releaseCountDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			t,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for t, v := range latest {
+		m := prometheus.MustNewConstMetric(
+			releaseLatestDesc,
+			prometheus.GaugeValue,
+			float64(v.Unix()),
+			r.Org,
+			r.Name,
+			t,
+		)
+		metrics = append(metrics, m)
+	}
+
+	if v, ok := latest[releaseTypeRelease]; ok {
+		m := prometheus.MustNewConstMetric(
+			releaseDaysSinceLatestDesc,
+			prometheus.GaugeValue,
+			now.Sub(v).Hours()/24,
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for n, release := range published {
+		if n >= c.recentReleases {
+			break
+		}
+
+		for _, asset := range release.Assets {
+			if !c.assetPattern.MatchString(asset.GetName()) {
+				continue
+			}
+
+			m := prometheus.MustNewConstMetric(
+				releaseAssetDownloadsDesc,
+				prometheus.CounterValue,
+				float64(asset.GetDownloadCount()),
+				r.Org,
+				r.Name,
+				release.GetTagName(),
+				asset.GetName(),
+			)
+			metrics = append(metrics, m)
+		}
+	}
+
+	return metrics
+}
@@ -0,0 +1,137 @@
+package collector
+
+import (
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_Collector_Release_releaseMetrics(t *testing.T) {
+	now := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+
+	release := func(tag string, prerelease bool, draft bool, published time.Time, assets map[string]int) *github.RepositoryRelease {
+		r := &github.RepositoryRelease{
+			Draft:       github.Bool(draft),
+			Prerelease:  github.Bool(prerelease),
+			PublishedAt: &github.Timestamp{Time: published},
+			TagName:     github.String(tag),
+		}
+		for name, count := range assets {
+			r.Assets = append(r.Assets, github.ReleaseAsset{
+				DownloadCount: github.Int(count),
+				Name:          github.String(name),
+			})
+		}
+
+		return r
+	}
+
+	testCases := []struct {
+		name              string
+		assetPattern      string
+		recentReleases    int
+		releases          []*github.RepositoryRelease
+		expectedCounts    map[string]float64
+		expectedDays      map[string]float64
+		expectedDownloads map[string]float64
+	}{
+		{
+			name:           "case 0 repository without releases",
+			recentReleases: 5,
+			expectedCounts: map[string]float64{
+				"prerelease": 0,
+				"release":    0,
+			},
+			expectedDays:      map[string]float64{},
+			expectedDownloads: map[string]float64{},
+		},
+		{
+			name:           "case 1 drafts are ignored",
+			recentReleases: 5,
+			releases: []*github.RepositoryRelease{
+				release("v1.1.0", false, true, time.Time{}, nil),
+				release("v1.1.0-rc1", true, false, now.Add(-2*day), nil),
+				release("v1.0.0", false, false, now.Add(-10*day), nil),
+			},
+			expectedCounts: map[string]float64{
+				"prerelease": 1,
+				"release":    1,
+			},
+			expectedDays: map[string]float64{
+				"giantswarm/test": 10,
+			},
+			expectedDownloads: map[string]float64{},
+		},
+		{
+			name:           "case 2 downloads of matching assets of recent releases",
+			assetPattern:   `linux`,
+			recentReleases: 2,
+			releases: []*github.RepositoryRelease{
+				release("v1.0.0", false, false, now.Add(-10*day), map[string]int{"linux-amd64": 3}),
+				release("v1.2.0", false, false, now.Add(-1*day), map[string]int{"linux-amd64": 7, "darwin-amd64": 2}),
+				release("v1.1.0", false, false, now.Add(-5*day), map[string]int{"linux-amd64": 11}),
+			},
+			expectedCounts: map[string]float64{
+				"prerelease": 0,
+				"release":    3,
+			},
+			expectedDays: map[string]float64{
+				"giantswarm/test": 1,
+			},
+			expectedDownloads: map[string]float64{
+				"v1.2.0/linux-amd64": 7,
+				"v1.1.0/linux-amd64": 11,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := &Release{
+				assetPattern:   regexp.MustCompile(tc.assetPattern),
+				recentReleases: tc.recentReleases,
+			}
+
+			counts := map[string]float64{}
+			days := map[string]float64{}
+			downloads := map[string]float64{}
+			for _, m := range c.releaseMetrics(Repository{Org: "giantswarm", Name: "test"}, tc.releases, now) {
+				var d dto.Metric
+				err := m.Write(&d)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				labels := map[string]string{}
+				for _, l := range d.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				switch m.Desc() {
+				case releaseCountDesc:
+					counts[labels[labelType]] = d.GetGauge().GetValue()
+				case releaseDaysSinceLatestDesc:
+					days[labels[labelOrg]+"/"+labels[labelRepo]] = d.GetGauge().GetValue()
+				case releaseAssetDownloadsDesc:
+					downloads[labels[labelRelease]+"/"+labels[labelAsset]] = d.GetCounter().GetValue()
+				}
+			}
+
+			if !cmp.Equal(counts, tc.expectedCounts) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(counts, tc.expectedCounts))
+			}
+			if !cmp.Equal(days, tc.expectedDays) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(days, tc.expectedDays))
+			}
+			if !cmp.Equal(downloads, tc.expectedDownloads) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(downloads, tc.expectedDownloads))
+			}
+		})
+	}
+}
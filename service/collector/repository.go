@@ -0,0 +1,43 @@
+package collector
+
+import (
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// Repository identifies a single Github repository by its organization and
+// its name, e.g. giantswarm/github-exporter.
+type Repository struct {
+	Org  string
+	Name string
+}
+
+// NewRepository parses the given string of the form org/repo.
+func NewRepository(s string) (Repository, error) {
+	split := strings.Split(s, "/")
+	if len(split) != 2 || split[0] == "" || split[1] == "" {
+		return Repository{}, microerror.Maskf(invalidConfigError, "repository %#q must be of the form org/repo", s)
+	}
+
+	r := Repository{
+		Org:  split[0],
+		Name: split[1],
+	}
+
+	return r, nil
+}
+
+func (r Repository) String() string {
+	return r.Org + "/" + r.Name
+}
+
+func containsRepository(repositories []Repository, r Repository) bool {
+	for _, c := range repositories {
+		if c == r {
+			return true
+		}
+	}
+
+	return false
+}
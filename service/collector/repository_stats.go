@@ -0,0 +1,244 @@
+package collector
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	repositoryStargazersDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "stargazers_count"),
+		"Users who starred the Github repository.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryForksDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "forks_count"),
+		"Forks of the Github repository.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositorySubscribersDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "subscribers_count"),
+		"Users watching the Github repository.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryOpenIssuesDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "open_issues_count"),
+		"Open issues and pull requests of the Github repository.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositorySizeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "size_kilobytes"),
+		"Size of the Github repository in kilobytes.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryPushedDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "pushed_timestamp_seconds"),
+		"Time of the last push to the Github repository as unix timestamp.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryArchivedDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "archived"),
+		"Whether the Github repository is archived.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryPrivateDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "private"),
+		"Whether the Github repository is private.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	repositoryInfoDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemRepository, "info"),
+		"Information about the Github repository. The value is always 1.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelDefaultBranch,
+			labelLanguage,
+			labelLicense,
+			labelTopics,
+		},
+		nil,
+	)
+)
+
+type RepositoryStatsConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+}
+
+// RepositoryStats collects statistics of repositories, e.g. their stars and
+// forks, as well as information like their topics and license.
+type RepositoryStats struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+
+	snapshot *snapshot
+}
+
+func NewRepositoryStats(config RepositoryStatsConfig) (*RepositoryStats, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	s := &RepositoryStats{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+
+		snapshot: newSnapshot(),
+	}
+
+	return s, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (s *RepositoryStats) Collect(ch chan<- prometheus.Metric) error {
+	s.snapshot.Collect(ch)
+	return nil
+}
+
+func (s *RepositoryStats) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- repositoryStargazersDesc
+	ch <- repositoryForksDesc
+	ch <- repositorySubscribersDesc
+	ch <- repositoryOpenIssuesDesc
+	ch <- repositorySizeDesc
+	ch <- repositoryPushedDesc
+	ch <- repositoryArchivedDesc
+	ch <- repositoryPrivateDesc
+	ch <- repositoryInfoDesc
+	return nil
+}
+
+// Refresh fetches all discovered repositories and replaces the snapshot
+// emitted by Collect.
+func (s *RepositoryStats) Refresh(ctx context.Context) error {
+	err := s.snapshot.Refresh(ctx, s.logger, s.discovery.Repositories(), s.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *RepositoryStats) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	repository, _, err := s.githubClient.Repositories.Get(ctx, r.Org, r.Name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return repositoryMetrics(r, repository), nil
+}
+
+func repositoryMetrics(r Repository, repository *github.Repository) []prometheus.Metric {
+	boolValue := func(b bool) float64 {
+		if b {
+			return 1
+		}
+		return 0
+	}
+
+	gauges := []struct {
+		Desc  *prometheus.Desc
+		Value float64
+	}{
+		{Desc: repositoryStargazersDesc, Value: float64(repository.GetStargazersCount())},
+		{Desc: repositoryForksDesc, Value: float64(repository.GetForksCount())},
+		{Desc: repositorySubscribersDesc, Value: float64(repository.GetSubscribersCount())},
+		{Desc: repositoryOpenIssuesDesc, Value: float64(repository.GetOpenIssuesCount())},
+		{Desc: repositorySizeDesc, Value: float64(repository.GetSize())},
+		{Desc: repositoryArchivedDesc, Value: boolValue(repository.GetArchived())},
+		{Desc: repositoryPrivateDesc, Value: boolValue(repository.GetPrivate())},
+	}
+
+	var metrics []prometheus.Metric
+
+	for _, g := range gauges {
+		m := prometheus.MustNewConstMetric(
+			g.Desc,
+			prometheus.GaugeValue,
+			g.Value,
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	// Repositories which were never pushed to do not have a push time.
+	if repository.PushedAt != nil {
+		m := prometheus.MustNewConstMetric(
+			repositoryPushedDesc,
+			prometheus.GaugeValue,
+			float64(repository.GetPushedAt().Unix()),
+			r.Org,
+			r.Name,
+		)
+		metrics = append(metrics, m)
+	}
+
+	{
+		topics := append([]string{}, repository.Topics...)
+		sort.Strings(topics)
+
+		m := prometheus.MustNewConstMetric(
+			repositoryInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			r.Org,
+			r.Name,
+			repository.GetDefaultBranch(),
+			repository.GetLanguage(),
+			repository.GetLicense().GetSPDXID(),
+			strings.Join(topics, ","),
+		)
+		metrics = append(metrics, m)
+	}
+
+	return metrics
+}
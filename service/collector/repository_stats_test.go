@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func Test_Collector_RepositoryStats_repositoryMetrics(t *testing.T) {
+	pushed := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		repository     *github.Repository
+		expectedGauges map[*prometheus.Desc]float64
+		expectedInfo   map[string]string
+	}{
+		{
+			name:       "case 0 empty repository",
+			repository: &github.Repository{},
+			expectedGauges: map[*prometheus.Desc]float64{
+				repositoryArchivedDesc:    0,
+				repositoryForksDesc:       0,
+				repositoryInfoDesc:        1,
+				repositoryOpenIssuesDesc:  0,
+				repositoryPrivateDesc:     0,
+				repositorySizeDesc:        0,
+				repositoryStargazersDesc:  0,
+				repositorySubscribersDesc: 0,
+			},
+			expectedInfo: map[string]string{
+				"default_branch": "",
+				"language":       "",
+				"license":        "",
+				"org":            "giantswarm",
+				"repo":           "test",
+				"topics":         "",
+			},
+		},
+		{
+			name: "case 1 repository with statistics and information",
+			repository: &github.Repository{
+				Archived:         github.Bool(true),
+				DefaultBranch:    github.String("master"),
+				ForksCount:       github.Int(3),
+				Language:         github.String("Go"),
+				License:          &github.License{SPDXID: github.String("Apache-2.0")},
+				OpenIssuesCount:  github.Int(7),
+				Private:          github.Bool(false),
+				PushedAt:         &github.Timestamp{Time: pushed},
+				Size:             github.Int(2048),
+				StargazersCount:  github.Int(42),
+				SubscribersCount: github.Int(5),
+				Topics:           []string{"prometheus", "github", "exporter"},
+			},
+			expectedGauges: map[*prometheus.Desc]float64{
+				repositoryArchivedDesc:    1,
+				repositoryForksDesc:       3,
+				repositoryInfoDesc:        1,
+				repositoryOpenIssuesDesc:  7,
+				repositoryPrivateDesc:     0,
+				repositoryPushedDesc:      float64(pushed.Unix()),
+				repositorySizeDesc:        2048,
+				repositoryStargazersDesc:  42,
+				repositorySubscribersDesc: 5,
+			},
+			expectedInfo: map[string]string{
+				"default_branch": "master",
+				"language":       "Go",
+				"license":        "Apache-2.0",
+				"org":            "giantswarm",
+				"repo":           "test",
+				"topics":         "exporter,github,prometheus",
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			gauges := map[*prometheus.Desc]float64{}
+			info := map[string]string{}
+			for _, m := range repositoryMetrics(Repository{Org: "giantswarm", Name: "test"}, tc.repository) {
+				var d dto.Metric
+				err := m.Write(&d)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				gauges[m.Desc()] = d.GetGauge().GetValue()
+
+				if m.Desc() == repositoryInfoDesc {
+					for _, l := range d.GetLabel() {
+						info[l.GetName()] = l.GetValue()
+					}
+				}
+			}
+
+			if !cmp.Equal(gauges, tc.expectedGauges) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(gauges, tc.expectedGauges))
+			}
+			if !cmp.Equal(info, tc.expectedInfo) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(info, tc.expectedInfo))
+			}
+		})
+	}
+}
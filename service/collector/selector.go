@@ -0,0 +1,378 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/giantswarm/microerror"
+)
+
+// selector matches the labels of Github issues. Selectors are parsed from
+// expressions of the following grammar.
+//
+//	or    = and { "OR" and }
+//	and   = not { ( "AND" | "," ) not }
+//	not   = "NOT" not | "(" or ")" | label
+//	label = name | '"' quoted name '"'
+//
+// Label names may contain the wildcards * and ?, which match any sequence of
+// characters and any single character respectively. So team/* matches every
+// label starting with team/. Consecutive words which are not keywords form a
+// single label name, so that labels like good first issue or area: kaas can be
+// given without quotes. Label names which contain commas, parentheses or
+// keywords have to be quoted.
+//
+// The comma is kept as an alias of AND so that plain lists of labels, e.g.
+// postmortem,team/batman, select issues having all of the given labels.
+type selector interface {
+	// Matches returns true if the given labels are selected.
+	Matches(labels []string) bool
+	// String returns the normalized form of the selector. Redundant whitespace
+	// and parentheses are removed and AND is written as a comma, so that plain
+	// lists of labels stay unchanged.
+	String() string
+}
+
+type andSelector []selector
+
+func (s andSelector) Matches(labels []string) bool {
+	for _, c := range s {
+		if !c.Matches(labels) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (s andSelector) String() string {
+	var l []string
+	for _, c := range s {
+		if _, ok := c.(orSelector); ok {
+			l = append(l, "("+c.String()+")")
+		} else {
+			l = append(l, c.String())
+		}
+	}
+
+	return strings.Join(l, ",")
+}
+
+type orSelector []selector
+
+func (s orSelector) Matches(labels []string) bool {
+	for _, c := range s {
+		if c.Matches(labels) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s orSelector) String() string {
+	var l []string
+	for _, c := range s {
+		l = append(l, c.String())
+	}
+
+	return strings.Join(l, " OR ")
+}
+
+type notSelector struct {
+	selector selector
+}
+
+func (s notSelector) Matches(labels []string) bool {
+	return !s.selector.Matches(labels)
+}
+
+func (s notSelector) String() string {
+	switch s.selector.(type) {
+	case andSelector, orSelector:
+		return "NOT (" + s.selector.String() + ")"
+	default:
+		return "NOT " + s.selector.String()
+	}
+}
+
+type labelSelector struct {
+	name string
+	// pattern is only set when name contains wildcards.
+	pattern *regexp.Regexp
+}
+
+func newLabelSelector(name string) labelSelector {
+	s := labelSelector{
+		name: name,
+	}
+
+	if strings.ContainsAny(name, "*?") {
+		expr := regexp.QuoteMeta(name)
+		expr = strings.Replace(expr, `\*`, ".*", -1)
+		expr = strings.Replace(expr, `\?`, ".", -1)
+		s.pattern = regexp.MustCompile("^" + expr + "$")
+	}
+
+	return s
+}
+
+func (s labelSelector) Matches(labels []string) bool {
+	for _, l := range labels {
+		if s.pattern != nil && s.pattern.MatchString(l) {
+			return true
+		}
+		if s.pattern == nil && s.name == l {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s labelSelector) String() string {
+	if s.needsQuotes() {
+		return `"` + s.name + `"`
+	}
+
+	return s.name
+}
+
+const (
+	tokenAnd = iota
+	tokenClose
+	tokenEnd
+	tokenLabel
+	tokenNot
+	tokenOpen
+	tokenOr
+)
+
+type token struct {
+	kind  int
+	value string
+	// quoted is true for labels given in quotes.
+	quoted bool
+	// position is the position of the token in the expression, starting at 1,
+	// so that it can be shown in error messages.
+	position int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of selector"
+	case tokenLabel:
+		return fmt.Sprintf("label %#q", t.value)
+	default:
+		return fmt.Sprintf("%#q", t.value)
+	}
+}
+
+// parseSelector parses the given expression. Parse errors are of type
+// invalidConfigError and contain the position of the offending token.
+func parseSelector(expr string) (selector, error) {
+	tokens, err := tokenizeSelector(expr)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "selector %#q: %s", expr, err)
+	}
+
+	p := &selectorParser{
+		tokens: tokens,
+	}
+
+	s, err := p.parseOr()
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "selector %#q: %s", expr, err)
+	}
+
+	if t := p.peek(); t.kind != tokenEnd {
+		return nil, microerror.Maskf(invalidConfigError, "selector %#q: unexpected %s at position %d", expr, t, t.position)
+	}
+
+	return s, nil
+}
+
+func tokenizeSelector(expr string) ([]token, error) {
+	var tokens []token
+
+	i := 0
+	for i < len(expr) {
+		c := expr[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenOpen, value: "(", position: i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenClose, value: ")", position: i + 1})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{kind: tokenAnd, value: ",", position: i + 1})
+			i++
+		case c == '"':
+			end := strings.IndexByte(expr[i+1:], '"')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated quote at position %d", i+1)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty label at position %d", i+1)
+			}
+			tokens = append(tokens, token{kind: tokenLabel, value: expr[i+1 : i+1+end], quoted: true, position: i + 1})
+			i += end + 2
+		default:
+			start := i
+			for i < len(expr) && !strings.ContainsRune(" \t\n(),\"", rune(expr[i])) {
+				i++
+			}
+
+			t := token{kind: tokenLabel, value: expr[start:i], position: start + 1}
+			switch t.value {
+			case "AND":
+				t.kind = tokenAnd
+			case "NOT":
+				t.kind = tokenNot
+			case "OR":
+				t.kind = tokenOr
+			}
+
+			// A word directly following an unquoted label, only separated by
+			// whitespace, continues its name.
+			if n := len(tokens); t.kind == tokenLabel && n != 0 && tokens[n-1].kind == tokenLabel && !tokens[n-1].quoted {
+				tokens[n-1].value = expr[tokens[n-1].position-1 : i]
+				continue
+			}
+
+			tokens = append(tokens, t)
+		}
+	}
+
+	tokens = append(tokens, token{kind: tokenEnd, position: len(expr) + 1})
+
+	return tokens, nil
+}
+
+type selectorParser struct {
+	tokens []token
+}
+
+func (p *selectorParser) next() token {
+	t := p.tokens[0]
+	if t.kind != tokenEnd {
+		p.tokens = p.tokens[1:]
+	}
+
+	return t
+}
+
+func (p *selectorParser) peek() token {
+	return p.tokens[0]
+}
+
+func (p *selectorParser) parseOr() (selector, error) {
+	var s orSelector
+
+	for {
+		c, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+
+		if o, ok := c.(orSelector); ok {
+			s = append(s, o...)
+		} else {
+			s = append(s, c)
+		}
+
+		if p.peek().kind != tokenOr {
+			break
+		}
+		p.next()
+	}
+
+	if len(s) == 1 {
+		return s[0], nil
+	}
+
+	return s, nil
+}
+
+func (p *selectorParser) parseAnd() (selector, error) {
+	var s andSelector
+
+	for {
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		if a, ok := c.(andSelector); ok {
+			s = append(s, a...)
+		} else {
+			s = append(s, c)
+		}
+
+		if p.peek().kind != tokenAnd {
+			break
+		}
+		p.next()
+	}
+
+	if len(s) == 1 {
+		return s[0], nil
+	}
+
+	return s, nil
+}
+
+func (p *selectorParser) parseNot() (selector, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenNot:
+		c, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		return notSelector{selector: c}, nil
+	case tokenOpen:
+		c, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if e := p.next(); e.kind != tokenClose {
+			return nil, fmt.Errorf("expected %#q to close %#q at position %d but got %s at position %d", ")", "(", t.position, e, e.position)
+		}
+
+		return c, nil
+	case tokenLabel:
+		return newLabelSelector(t.value), nil
+	default:
+		return nil, fmt.Errorf("expected label, %#q or %#q but got %s at position %d", "NOT", "(", t, t.position)
+	}
+}
+
+// needsQuotes returns true if the name of the label cannot be given without
+// quotes, because it would be parsed differently.
+func (s labelSelector) needsQuotes() bool {
+	if strings.ContainsAny(s.name, ",()\"") || s.name != strings.TrimSpace(s.name) {
+		return true
+	}
+	for _, w := range strings.Fields(s.name) {
+		if isKeyword(w) {
+			return true
+		}
+	}
+
+	// Whitespace within the name is only kept if it consists of single spaces.
+	return strings.Join(strings.Fields(s.name), " ") != s.name
+}
+
+func isKeyword(s string) bool {
+	return s == "AND" || s == "NOT" || s == "OR"
+}
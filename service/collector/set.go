@@ -1,17 +1,61 @@
 package collector
 
 import (
+	"context"
+	"time"
+
 	"github.com/giantswarm/exporterkit/collector"
 	"github.com/giantswarm/microerror"
 	"github.com/giantswarm/micrologger"
 	"github.com/google/go-github/github"
+
+	"github.com/giantswarm/github-exporter/service/store"
 )
 
 type SetConfig struct {
+	// Collectors are additional collectors created outside of this package,
+	// e.g. the ones exposing metrics of the Github API transport.
+	Collectors   []collector.Interface
 	GithubClient *github.Client
 	Logger       micrologger.Logger
+	Store        store.Interface
 
-	CustomLabels []string
+	BreakdownLimit         int
+	CheckEnabled           bool
+	BreakdownSelectors     []string
+	BreakdownTeams         []string
+	BreakdownUsers         []string
+	CustomLabels           []string
+	Dimensions             []string
+	DiscoveryExclude       []string
+	DiscoveryInclude       []string
+	DiscoveryInterval      time.Duration
+	DiscoveryOrganizations []string
+	DiscoverySkipArchived  bool
+	DiscoverySkipForks     bool
+	DiscoverySkipPrivate   bool
+	FirstResponseBudget    int
+	FirstResponseEnabled   bool
+	FirstResponseWindow    time.Duration
+	Interval               time.Duration
+	LifetimeBuckets        []float64
+	MilestoneEnabled       bool
+	MilestoneState         string
+	PullRequestBuckets     []float64
+	PullRequestEnabled     bool
+	PullRequestRetention   time.Duration
+	ReleaseAssetPattern    string
+	ReleaseEnabled         bool
+	ReleaseRecentReleases  int
+	Repositories           []Repository
+	RepositoryEnabled      bool
+	Retention              time.Duration
+	StaleThresholds        []time.Duration
+	TrafficEnabled         bool
+	TrafficTopN            int
+	WorkflowBuckets        []float64
+	WorkflowEnabled        bool
+	WorkflowRetention      time.Duration
 }
 
 // Set is basically only a wrapper for the operator's collector implementations.
@@ -19,18 +63,56 @@ type SetConfig struct {
 // have to alias packages.
 type Set struct {
 	*collector.Set
+
+	discovery *Discovery
+	poller    *Poller
 }
 
 func NewSet(config SetConfig) (*Set, error) {
 	var err error
 
+	var discovery *Discovery
+	{
+		c := DiscoveryConfig{
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+
+			Exclude:       config.DiscoveryExclude,
+			Include:       config.DiscoveryInclude,
+			Interval:      config.DiscoveryInterval,
+			Organizations: config.DiscoveryOrganizations,
+			Repositories:  config.Repositories,
+			SkipArchived:  config.DiscoverySkipArchived,
+			SkipForks:     config.DiscoverySkipForks,
+			SkipPrivate:   config.DiscoverySkipPrivate,
+		}
+
+		discovery, err = NewDiscovery(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var issueCollector *Issue
 	{
 		c := IssueConfig{
+			Discovery:    discovery,
 			GithubClient: config.GithubClient,
 			Logger:       config.Logger,
+			Store:        config.Store,
 
-			CustomLabels: config.CustomLabels,
+			BreakdownLimit:       config.BreakdownLimit,
+			BreakdownSelectors:   config.BreakdownSelectors,
+			BreakdownTeams:       config.BreakdownTeams,
+			BreakdownUsers:       config.BreakdownUsers,
+			CustomLabels:         config.CustomLabels,
+			Dimensions:           config.Dimensions,
+			FirstResponseBudget:  config.FirstResponseBudget,
+			FirstResponseEnabled: config.FirstResponseEnabled,
+			FirstResponseWindow:  config.FirstResponseWindow,
+			LifetimeBuckets:      config.LifetimeBuckets,
+			Retention:            config.Retention,
+			StaleThresholds:      config.StaleThresholds,
 		}
 
 		issueCollector, err = NewIssue(c)
@@ -39,13 +121,175 @@ func NewSet(config SetConfig) (*Set, error) {
 		}
 	}
 
+	var pullRequestCollector *PullRequest
+	if config.PullRequestEnabled {
+		c := PullRequestConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+			Store:        config.Store,
+
+			Buckets:   config.PullRequestBuckets,
+			Retention: config.PullRequestRetention,
+		}
+
+		pullRequestCollector, err = NewPullRequest(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var checkCollector *Check
+	if config.CheckEnabled {
+		c := CheckConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+			Store:        config.Store,
+		}
+
+		checkCollector, err = NewCheck(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var milestoneCollector *Milestone
+	if config.MilestoneEnabled {
+		c := MilestoneConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+
+			State: config.MilestoneState,
+		}
+
+		milestoneCollector, err = NewMilestone(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var releaseCollector *Release
+	if config.ReleaseEnabled {
+		c := ReleaseConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+
+			AssetPattern:   config.ReleaseAssetPattern,
+			RecentReleases: config.ReleaseRecentReleases,
+		}
+
+		releaseCollector, err = NewRelease(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var repositoryStatsCollector *RepositoryStats
+	if config.RepositoryEnabled {
+		c := RepositoryStatsConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+		}
+
+		repositoryStatsCollector, err = NewRepositoryStats(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var trafficCollector *Traffic
+	if config.TrafficEnabled {
+		c := TrafficConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+			Store:        config.Store,
+
+			TopN: config.TrafficTopN,
+		}
+
+		trafficCollector, err = NewTraffic(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var workflowCollector *Workflow
+	if config.WorkflowEnabled {
+		c := WorkflowConfig{
+			Discovery:    discovery,
+			GithubClient: config.GithubClient,
+			Logger:       config.Logger,
+			Store:        config.Store,
+
+			Buckets:   config.WorkflowBuckets,
+			Retention: config.WorkflowRetention,
+		}
+
+		workflowCollector, err = NewWorkflow(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	collectors := append([]collector.Interface{}, config.Collectors...)
+	collectors = append(collectors, issueCollector)
+	refreshers := map[string]Refresher{
+		"issue": issueCollector,
+	}
+	if checkCollector != nil {
+		collectors = append(collectors, checkCollector)
+		refreshers["check"] = checkCollector
+	}
+	if milestoneCollector != nil {
+		collectors = append(collectors, milestoneCollector)
+		refreshers["milestone"] = milestoneCollector
+	}
+	if pullRequestCollector != nil {
+		collectors = append(collectors, pullRequestCollector)
+		refreshers["pull_request"] = pullRequestCollector
+	}
+	if releaseCollector != nil {
+		collectors = append(collectors, releaseCollector)
+		refreshers["release"] = releaseCollector
+	}
+	if repositoryStatsCollector != nil {
+		collectors = append(collectors, repositoryStatsCollector)
+		refreshers["repository"] = repositoryStatsCollector
+	}
+	if trafficCollector != nil {
+		collectors = append(collectors, trafficCollector)
+		refreshers["traffic"] = trafficCollector
+	}
+	if workflowCollector != nil {
+		collectors = append(collectors, workflowCollector)
+		refreshers["workflow"] = workflowCollector
+	}
+
+	var poller *Poller
+	{
+		c := PollerConfig{
+			Logger:     config.Logger,
+			Refreshers: refreshers,
+
+			Interval: config.Interval,
+		}
+
+		poller, err = NewPoller(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
 	var collectorSet *collector.Set
 	{
 		c := collector.SetConfig{
-			Collectors: []collector.Interface{
-				issueCollector,
-			},
-			Logger: config.Logger,
+			Collectors: append(collectors, poller),
+			Logger:     config.Logger,
 		}
 
 		collectorSet, err = collector.NewSet(c)
@@ -56,7 +300,20 @@ func NewSet(config SetConfig) (*Set, error) {
 
 	s := &Set{
 		Set: collectorSet,
+
+		discovery: discovery,
+		poller:    poller,
 	}
 
 	return s, nil
 }
+
+// Boot discovers the repositories to collect, refreshes all collectors once
+// and then keeps polling the Github API in the background until the given
+// context is done. The set is not registered with Prometheus. The caller is
+// responsible for collecting it, which allows replacing it on reload. Failing
+// refreshes do not stop the set. They are logged and exported by the poller.
+func (s *Set) Boot(ctx context.Context) {
+	s.discovery.Boot(ctx)
+	s.poller.Boot(ctx)
+}
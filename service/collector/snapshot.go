@@ -0,0 +1,72 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// snapshot holds the metrics of the latest refresh per repository. It is
+// shared by all collectors which implement Refresher.
+type snapshot struct {
+	metrics map[Repository][]prometheus.Metric
+	mutex   sync.RWMutex
+}
+
+func newSnapshot() *snapshot {
+	s := &snapshot{
+		metrics: map[Repository][]prometheus.Metric{},
+		mutex:   sync.RWMutex{},
+	}
+
+	return s
+}
+
+func (s *snapshot) Collect(ch chan<- prometheus.Metric) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, metrics := range s.metrics {
+		for _, m := range metrics {
+			ch <- m
+		}
+	}
+}
+
+// Refresh calls refreshFunc for all given repositories and replaces the
+// snapshot with the returned metrics. Repositories which fail to be refreshed
+// keep their previous metrics, while repositories which are not given anymore
+// are dropped from the snapshot. The last refresh error, if any, is returned
+// after all repositories were processed.
+func (s *snapshot) Refresh(ctx context.Context, logger micrologger.Logger, repositories []Repository, refreshFunc func(ctx context.Context, r Repository) ([]prometheus.Metric, error)) error {
+	var refreshErr error
+	metrics := map[Repository][]prometheus.Metric{}
+
+	for _, r := range repositories {
+		m, err := refreshFunc(ctx, r)
+		if err != nil {
+			logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed refreshing repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+			refreshErr = err
+
+			s.mutex.RLock()
+			m = s.metrics[r]
+			s.mutex.RUnlock()
+		}
+
+		metrics[r] = m
+	}
+
+	s.mutex.Lock()
+	s.metrics = metrics
+	s.mutex.Unlock()
+
+	if refreshErr != nil {
+		return microerror.Mask(refreshErr)
+	}
+
+	return nil
+}
@@ -0,0 +1,389 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/github-exporter/service/store"
+)
+
+var (
+	trafficViewsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "views_total"),
+		"Views of the Github repository seen by the exporter.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	trafficViewsUniqueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "views_unique_total"),
+		"Sum of the daily unique visitors of the Github repository seen by the exporter.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	trafficClonesDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "clones_total"),
+		"Clones of the Github repository seen by the exporter.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	trafficClonesUniqueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "clones_unique_total"),
+		"Sum of the daily unique cloners of the Github repository seen by the exporter.",
+		[]string{
+			labelOrg,
+			labelRepo,
+		},
+		nil,
+	)
+	trafficReferrerViewsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "referrer_views_count"),
+		"Views of the Github repository within the last 14 days per top referrer.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelReferrer,
+		},
+		nil,
+	)
+	trafficReferrerViewsUniqueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "referrer_views_unique_count"),
+		"Unique visitors of the Github repository within the last 14 days per top referrer.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelReferrer,
+		},
+		nil,
+	)
+	trafficPathViewsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "path_views_count"),
+		"Views of the Github repository within the last 14 days per top path.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelPath,
+		},
+		nil,
+	)
+	trafficPathViewsUniqueDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemTraffic, "path_views_unique_count"),
+		"Unique visitors of the Github repository within the last 14 days per top path.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelPath,
+		},
+		nil,
+	)
+)
+
+const (
+	// trafficFinalAge is the age after which the traffic of a day is not
+	// returned by the Traffic API anymore and thus cannot change anymore. The
+	// API returns the last 14 days. One more day accounts for time zones and
+	// the current day being part of the response.
+	trafficFinalAge = 15 * 24 * time.Hour
+	// trafficDayFormat formats the days of the traffic data in map keys.
+	trafficDayFormat = "2006-01-02"
+)
+
+type TrafficConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+	// Store persists the traffic seen so far, so that the totals survive
+	// restarts and are not limited to the 14 days the Traffic API returns.
+	Store store.Interface
+
+	// TopN is the maximum number of referrers and paths exported per
+	// repository. Defaults to 10, which is also the maximum the Traffic API
+	// returns.
+	TopN int
+}
+
+type Traffic struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+	store        store.Interface
+
+	snapshot *snapshot
+
+	topN int
+}
+
+// trafficCounter accumulates the daily traffic of a repository. Final holds
+// the sum of all days up to Until, which are not returned by the Traffic API
+// anymore. Days holds the days after Until, whose traffic is overwritten with
+// every refresh, because the traffic of the current day still grows.
+// Overlapping responses of the Traffic API thus never count a day twice.
+type trafficCounter struct {
+	Days  map[string]trafficCount
+	Final trafficCount
+	Until time.Time
+}
+
+type trafficCount struct {
+	Count   int
+	Uniques int
+}
+
+// trafficSync holds the traffic of a repository seen so far.
+type trafficSync struct {
+	Clones trafficCounter
+	Views  trafficCounter
+}
+
+func NewTraffic(config TrafficConfig) (*Traffic, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	if config.TopN == 0 {
+		config.TopN = 10
+	}
+	if config.TopN < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.TopN must not be negative", config)
+	}
+
+	t := &Traffic{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+		store:        config.Store,
+
+		snapshot: newSnapshot(),
+
+		topN: config.TopN,
+	}
+
+	return t, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (t *Traffic) Collect(ch chan<- prometheus.Metric) error {
+	t.snapshot.Collect(ch)
+	return nil
+}
+
+func (t *Traffic) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- trafficViewsDesc
+	ch <- trafficViewsUniqueDesc
+	ch <- trafficClonesDesc
+	ch <- trafficClonesUniqueDesc
+	ch <- trafficReferrerViewsDesc
+	ch <- trafficReferrerViewsUniqueDesc
+	ch <- trafficPathViewsDesc
+	ch <- trafficPathViewsUniqueDesc
+	return nil
+}
+
+// Refresh fetches the traffic of all discovered repositories, merges the daily
+// views and clones into the traffic seen so far and replaces the snapshot
+// emitted by Collect.
+func (t *Traffic) Refresh(ctx context.Context) error {
+	err := t.snapshot.Refresh(ctx, t.logger, t.discovery.Repositories(), t.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (t *Traffic) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	opts := &github.TrafficBreakdownOptions{
+		Per: "day",
+	}
+
+	views, _, err := t.githubClient.Repositories.ListTrafficViews(ctx, r.Org, r.Name, opts)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	clones, _, err := t.githubClient.Repositories.ListTrafficClones(ctx, r.Org, r.Name, opts)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	referrers, _, err := t.githubClient.Repositories.ListTrafficReferrers(ctx, r.Org, r.Name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	paths, _, err := t.githubClient.Repositories.ListTrafficPaths(ctx, r.Org, r.Name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	synced := &trafficSync{}
+	{
+		_, err := t.store.Get(trafficStoreKey(r), synced)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		synced.Views.Merge(views.Views, now)
+		synced.Clones.Merge(clones.Clones, now)
+
+		err = t.store.Put(trafficStoreKey(r), synced)
+		if err != nil {
+			t.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed storing traffic of repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	{
+		counters := []struct {
+			Desc  *prometheus.Desc
+			Value int
+		}{
+			{Desc: trafficViewsDesc, Value: synced.Views.Total().Count},
+			{Desc: trafficViewsUniqueDesc, Value: synced.Views.Total().Uniques},
+			{Desc: trafficClonesDesc, Value: synced.Clones.Total().Count},
+			{Desc: trafficClonesUniqueDesc, Value: synced.Clones.Total().Uniques},
+		}
+
+		for _, c := range counters {
+			m := prometheus.MustNewConstMetric(
+				c.Desc,
+				prometheus.CounterValue,
+				float64(c.Value),
+				r.Org,
+				r.Name,
+			)
+			metrics = append(metrics, m)
+		}
+	}
+
+	{
+		top := map[string]trafficCount{}
+		for _, referrer := range referrers {
+			top[referrer.GetReferrer()] = trafficCount{Count: referrer.GetCount(), Uniques: referrer.GetUniques()}
+		}
+
+		for k, v := range topTraffic(top, t.topN) {
+			metrics = append(metrics, trafficMetrics(trafficReferrerViewsDesc, trafficReferrerViewsUniqueDesc, v, r.Org, r.Name, k)...)
+		}
+	}
+
+	{
+		top := map[string]trafficCount{}
+		for _, path := range paths {
+			top[path.GetPath()] = trafficCount{Count: path.GetCount(), Uniques: path.GetUniques()}
+		}
+
+		for k, v := range topTraffic(top, t.topN) {
+			metrics = append(metrics, trafficMetrics(trafficPathViewsDesc, trafficPathViewsUniqueDesc, v, r.Org, r.Name, k)...)
+		}
+	}
+
+	return metrics, nil
+}
+
+// Merge merges the given daily traffic returned by the Traffic API into the
+// counter. Days which are older than trafficFinalAge are added to the final
+// traffic, since they cannot change anymore. Days up to Until were already
+// added to the final traffic and are ignored.
+func (c *trafficCounter) Merge(data []*github.TrafficData, now time.Time) {
+	if c.Days == nil {
+		c.Days = map[string]trafficCount{}
+	}
+
+	for _, d := range data {
+		day := d.GetTimestamp().UTC()
+		if !day.After(c.Until) {
+			continue
+		}
+
+		c.Days[day.Format(trafficDayFormat)] = trafficCount{
+			Count:   d.GetCount(),
+			Uniques: d.GetUniques(),
+		}
+	}
+
+	until := now.Add(-trafficFinalAge).UTC()
+	for k, v := range c.Days {
+		day, err := time.Parse(trafficDayFormat, k)
+		if err != nil || !day.After(until) {
+			c.Final.Count += v.Count
+			c.Final.Uniques += v.Uniques
+			delete(c.Days, k)
+		}
+	}
+	if until.After(c.Until) {
+		c.Until = until
+	}
+}
+
+// Total returns the traffic of all days seen so far.
+func (c *trafficCounter) Total() trafficCount {
+	total := c.Final
+	for _, v := range c.Days {
+		total.Count += v.Count
+		total.Uniques += v.Uniques
+	}
+
+	return total
+}
+
+func trafficStoreKey(r Repository) string {
+	return "traffic/" + r.String()
+}
+
+func trafficMetrics(viewsDesc *prometheus.Desc, uniqueDesc *prometheus.Desc, v trafficCount, labelValues ...string) []prometheus.Metric {
+	return []prometheus.Metric{
+		prometheus.MustNewConstMetric(viewsDesc, prometheus.GaugeValue, float64(v.Count), labelValues...),
+		prometheus.MustNewConstMetric(uniqueDesc, prometheus.GaugeValue, float64(v.Uniques), labelValues...),
+	}
+}
+
+// topTraffic returns the given number of entries of the given traffic with
+// the most views. Entries with the same number of views are ordered by name.
+func topTraffic(traffic map[string]trafficCount, n int) map[string]trafficCount {
+	var keys []string
+	for k := range traffic {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(a, b int) bool {
+		if traffic[keys[a]].Count != traffic[keys[b]].Count {
+			return traffic[keys[a]].Count > traffic[keys[b]].Count
+		}
+		return keys[a] < keys[b]
+	})
+
+	top := map[string]trafficCount{}
+	for i, k := range keys {
+		if i >= n {
+			break
+		}
+		top[k] = traffic[k]
+	}
+
+	return top
+}
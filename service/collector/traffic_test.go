@@ -0,0 +1,141 @@
+package collector
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+)
+
+func Test_Collector_Traffic_trafficCounter(t *testing.T) {
+	day := 24 * time.Hour
+	start := time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	data := func(from int, to int, count int) []*github.TrafficData {
+		var l []*github.TrafficData
+		for d := from; d <= to; d++ {
+			l = append(l, &github.TrafficData{
+				Count:     github.Int(count),
+				Timestamp: &github.Timestamp{Time: start.Add(time.Duration(d) * day)},
+				Uniques:   github.Int(1),
+			})
+		}
+
+		return l
+	}
+
+	type merge struct {
+		data []*github.TrafficData
+		now  time.Time
+	}
+
+	testCases := []struct {
+		name          string
+		merges        []merge
+		expectedTotal trafficCount
+	}{
+		{
+			name: "case 0 single response",
+			merges: []merge{
+				{data: data(0, 13, 2), now: start.Add(13 * day)},
+			},
+			expectedTotal: trafficCount{Count: 28, Uniques: 14},
+		},
+		{
+			name: "case 1 overlapping responses count every day once",
+			merges: []merge{
+				{data: data(0, 13, 2), now: start.Add(13 * day)},
+				{data: data(1, 14, 2), now: start.Add(14 * day)},
+				{data: data(2, 15, 2), now: start.Add(15 * day)},
+			},
+			expectedTotal: trafficCount{Count: 32, Uniques: 16},
+		},
+		{
+			name: "case 2 growing traffic of the current day is not added up",
+			merges: []merge{
+				{data: data(0, 0, 1), now: start.Add(time.Hour)},
+				{data: data(0, 0, 3), now: start.Add(2 * time.Hour)},
+				{data: data(0, 0, 5), now: start.Add(3 * time.Hour)},
+			},
+			expectedTotal: trafficCount{Count: 5, Uniques: 1},
+		},
+		{
+			name: "case 3 final days are kept after they left the response",
+			merges: []merge{
+				{data: data(0, 13, 2), now: start.Add(13 * day)},
+				{data: data(30, 43, 1), now: start.Add(43 * day)},
+				{data: data(31, 44, 1), now: start.Add(44 * day)},
+			},
+			expectedTotal: trafficCount{Count: 43, Uniques: 29},
+		},
+		{
+			name: "case 4 final days returned again are ignored",
+			merges: []merge{
+				{data: data(0, 13, 2), now: start.Add(13 * day)},
+				{data: data(0, 13, 2), now: start.Add(30 * day)},
+			},
+			expectedTotal: trafficCount{Count: 28, Uniques: 14},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var c trafficCounter
+			for _, m := range tc.merges {
+				c.Merge(m.data, m.now)
+			}
+
+			total := c.Total()
+			if total != tc.expectedTotal {
+				t.Fatalf("\n\n%s\n", cmp.Diff(total, tc.expectedTotal))
+			}
+		})
+	}
+}
+
+func Test_Collector_Traffic_topTraffic(t *testing.T) {
+	testCases := []struct {
+		name           string
+		traffic        map[string]trafficCount
+		n              int
+		expectedResult map[string]trafficCount
+	}{
+		{
+			name: "case 0 traffic within the limit",
+			traffic: map[string]trafficCount{
+				"github.com": {Count: 3, Uniques: 2},
+				"google.com": {Count: 5, Uniques: 4},
+			},
+			n: 10,
+			expectedResult: map[string]trafficCount{
+				"github.com": {Count: 3, Uniques: 2},
+				"google.com": {Count: 5, Uniques: 4},
+			},
+		},
+		{
+			name: "case 1 traffic exceeding the limit",
+			traffic: map[string]trafficCount{
+				"bing.com":   {Count: 3, Uniques: 1},
+				"github.com": {Count: 3, Uniques: 2},
+				"google.com": {Count: 5, Uniques: 4},
+			},
+			n: 2,
+			expectedResult: map[string]trafficCount{
+				"bing.com":   {Count: 3, Uniques: 1},
+				"google.com": {Count: 5, Uniques: 4},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := topTraffic(tc.traffic, tc.n)
+
+			if !cmp.Equal(result, tc.expectedResult) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
@@ -0,0 +1,499 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-github/github"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/giantswarm/github-exporter/service/store"
+)
+
+var (
+	workflowRunsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemWorkflow, "runs_count"),
+		"Completed Github Actions workflow runs created within the retention.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelWorkflow,
+			labelBranch,
+			labelEvent,
+			labelConclusion,
+		},
+		nil,
+	)
+	workflowRunsActiveDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemWorkflow, "runs_active_count"),
+		"Github Actions workflow runs which are currently queued or in progress.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelWorkflow,
+			labelStatus,
+		},
+		nil,
+	)
+	workflowRunDurationDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemWorkflow, "run_duration_seconds"),
+		"Time between starting and completing Github Actions workflow runs.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelWorkflow,
+		},
+		nil,
+	)
+	workflowRunQueueDurationDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemWorkflow, "run_queue_duration_seconds"),
+		"Time between creating and starting Github Actions workflow runs.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelWorkflow,
+		},
+		nil,
+	)
+	workflowLastSuccessAgeDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystemWorkflow, "last_success_age_seconds"),
+		"Time since the latest successful Github Actions workflow run on the default branch was created, as of the last refresh.",
+		[]string{
+			labelOrg,
+			labelRepo,
+			labelWorkflow,
+		},
+		nil,
+	)
+)
+
+const (
+	workflowStatusCompleted  = "completed"
+	workflowStatusInProgress = "in_progress"
+	workflowStatusQueued     = "queued"
+
+	workflowConclusionSuccess = "success"
+)
+
+var (
+	// defaultWorkflowBuckets ranges from 15 seconds to 256 minutes.
+	defaultWorkflowBuckets = prometheus.ExponentialBuckets(15, 2, 11)
+)
+
+type WorkflowConfig struct {
+	Discovery    *Discovery
+	GithubClient *github.Client
+	Logger       micrologger.Logger
+	// Store persists the workflow runs of each repository, so that a restart
+	// does not cause a full sync.
+	Store store.Interface
+
+	// Buckets are the upper bounds in seconds of the buckets of the run and
+	// queue duration histograms. Defaults to exponential buckets ranging from
+	// 15 seconds to 256 minutes.
+	Buckets []float64
+	// Retention is the time after which workflow runs are dropped. It also
+	// limits the initial sync to workflow runs created within the retention.
+	// Defaults to 7 days.
+	Retention time.Duration
+}
+
+type Workflow struct {
+	discovery    *Discovery
+	githubClient *github.Client
+	logger       micrologger.Logger
+	store        store.Interface
+
+	runs     map[Repository]*workflowSync
+	snapshot *snapshot
+
+	buckets   []float64
+	retention time.Duration
+}
+
+// workflowRun is a Github Actions workflow run as returned by the API. The
+// vendored Github client does not support the Actions API, so workflow runs
+// are requested and decoded directly.
+type workflowRun struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	HeadBranch   string    `json:"head_branch"`
+	Event        string    `json:"event"`
+	Status       string    `json:"status"`
+	Conclusion   string    `json:"conclusion"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	RunStartedAt time.Time `json:"run_started_at"`
+}
+
+type workflowRunList struct {
+	TotalCount   int            `json:"total_count"`
+	WorkflowRuns []*workflowRun `json:"workflow_runs"`
+}
+
+// workflowSync holds the workflow runs of a repository created within the
+// retention and the latest successful run of each workflow on the default
+// branch, which is kept regardless of the retention.
+type workflowSync struct {
+	Cursor      time.Time
+	LastSuccess map[string]time.Time
+	Runs        map[int64]workflowRun
+}
+
+func NewWorkflow(config WorkflowConfig) (*Workflow, error) {
+	if config.Discovery == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Discovery must not be empty", config)
+	}
+	if config.GithubClient == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.GithubClient must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Store == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Store must not be empty", config)
+	}
+
+	if len(config.Buckets) == 0 {
+		config.Buckets = defaultWorkflowBuckets
+	}
+	if !sort.Float64sAreSorted(config.Buckets) {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Buckets must be in increasing order", config)
+	}
+	if config.Retention == 0 {
+		config.Retention = 7 * 24 * time.Hour
+	}
+	if config.Retention < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Retention must not be negative", config)
+	}
+
+	w := &Workflow{
+		discovery:    config.Discovery,
+		githubClient: config.GithubClient,
+		logger:       config.Logger,
+		store:        config.Store,
+
+		runs:     map[Repository]*workflowSync{},
+		snapshot: newSnapshot(),
+
+		buckets:   config.Buckets,
+		retention: config.Retention,
+	}
+
+	return w, nil
+}
+
+// Collect emits the metrics of the latest snapshot. See Refresh.
+func (w *Workflow) Collect(ch chan<- prometheus.Metric) error {
+	w.snapshot.Collect(ch)
+	return nil
+}
+
+func (w *Workflow) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- workflowRunsDesc
+	ch <- workflowRunsActiveDesc
+	ch <- workflowRunDurationDesc
+	ch <- workflowRunQueueDurationDesc
+	ch <- workflowLastSuccessAgeDesc
+	return nil
+}
+
+// Refresh fetches the workflow runs of all discovered repositories created
+// since the last refresh, merges them into the workflow runs known so far and
+// replaces the snapshot emitted by Collect.
+func (w *Workflow) Refresh(ctx context.Context) error {
+	repositories := w.discovery.Repositories()
+
+	for r := range w.runs {
+		if !containsRepository(repositories, r) {
+			delete(w.runs, r)
+		}
+	}
+
+	err := w.snapshot.Refresh(ctx, w.logger, repositories, w.refreshRepository)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (w *Workflow) refreshRepository(ctx context.Context, r Repository) ([]prometheus.Metric, error) {
+	now := time.Now()
+
+	synced, ok := w.runs[r]
+	if !ok {
+		var err error
+		synced, err = w.loadSync(ctx, r)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	repository, _, err := w.githubClient.Repositories.Get(ctx, r.Org, r.Name)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	// Workflow runs can only be filtered by their creation time, but their
+	// status changes until they are completed. So runs are fetched again
+	// starting with the oldest run which was not completed yet.
+	//
+	// Re-running a completed run keeps its ID and creation time, so re-runs of
+	// runs created before the cursor are not fetched again. They keep the
+	// status and conclusion of their previous attempt until they are dropped
+	// after the retention. The API cannot filter by update time, and fetching
+	// all runs within the retention on every refresh would cost too many
+	// requests for busy repositories.
+	since := synced.Cursor
+	if since.IsZero() {
+		since = now.Add(-w.retention)
+	}
+	for _, run := range synced.Runs {
+		if run.Status != workflowStatusCompleted && run.CreatedAt.Before(since) {
+			since = run.CreatedAt
+		}
+	}
+
+	runs, err := w.listRuns(ctx, r, since)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	for _, run := range runs {
+		synced.Runs[run.ID] = *run
+
+		if run.CreatedAt.After(synced.Cursor) {
+			synced.Cursor = run.CreatedAt
+		}
+
+		if run.HeadBranch == repository.GetDefaultBranch() && run.Conclusion == workflowConclusionSuccess {
+			if run.CreatedAt.After(synced.LastSuccess[run.Name]) {
+				synced.LastSuccess[run.Name] = run.CreatedAt
+			}
+		}
+	}
+
+	for id, run := range synced.Runs {
+		if run.CreatedAt.Before(now.Add(-w.retention)) {
+			delete(synced.Runs, id)
+		}
+	}
+
+	w.runs[r] = synced
+
+	// Failing to persist the sync only means that the next restart has to sync
+	// more workflow runs, so the refresh does not fail because of it.
+	err = w.store.Put(workflowStoreKey(r), synced)
+	if err != nil {
+		w.logger.LogCtx(ctx, "level", "warning", "message", fmt.Sprintf("failed storing workflow runs of repository %#q", r.String()), "stack", fmt.Sprintf("%#v", err))
+	}
+
+	return w.workflowMetrics(r, synced, now), nil
+}
+
+// loadSync returns the sync of the given repository persisted by a previous
+// run of the exporter, or an empty sync if there is none.
+func (w *Workflow) loadSync(ctx context.Context, r Repository) (*workflowSync, error) {
+	synced := &workflowSync{}
+
+	ok, err := w.store.Get(workflowStoreKey(r), synced)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	if ok {
+		w.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("loaded %d stored workflow runs for repository %#q", len(synced.Runs), r.String()))
+	}
+	if synced.LastSuccess == nil {
+		synced.LastSuccess = map[string]time.Time{}
+	}
+	if synced.Runs == nil {
+		synced.Runs = map[int64]workflowRun{}
+	}
+
+	return synced, nil
+}
+
+// listRuns returns the workflow runs of the given repository created since
+// the given time.
+func (w *Workflow) listRuns(ctx context.Context, r Repository, since time.Time) ([]*workflowRun, error) {
+	page := 1
+
+	var runs []*workflowRun
+
+	for {
+		query := url.Values{}
+		query.Set("created", ">="+since.UTC().Format(time.RFC3339))
+		query.Set("page", fmt.Sprintf("%d", page))
+		query.Set("per_page", "100")
+
+		req, err := w.githubClient.NewRequest("GET", fmt.Sprintf("repos/%s/%s/actions/runs?%s", r.Org, r.Name, query.Encode()), nil)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		var list workflowRunList
+		res, err := w.githubClient.Do(ctx, req, &list)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		w.logger.LogCtx(ctx, "level", "debug", "message", fmt.Sprintf("collecting %3d workflow runs of page %2d for repository %#q", len(list.WorkflowRuns), page, r.String()))
+
+		runs = append(runs, list.WorkflowRuns...)
+
+		if res.NextPage == 0 {
+			break
+		}
+		page = res.NextPage
+	}
+
+	return runs, nil
+}
+
+func (w *Workflow) workflowMetrics(r Repository, synced *workflowSync, now time.Time) []prometheus.Metric {
+	type key struct {
+		Workflow   string
+		Branch     string
+		Event      string
+		Conclusion string
+	}
+	type activeKey struct {
+		Workflow string
+		Status   string
+	}
+
+	active := map[activeKey]float64{}
+	durations := map[string]*histogram{}
+	queueDurations := map[string]*histogram{}
+	runs := map[key]float64{}
+
+	observe := func(m map[string]*histogram, workflow string, v float64) {
+		h, ok := m[workflow]
+		if !ok {
+			h = newHistogram(w.buckets)
+			m[workflow] = h
+		}
+
+		h.Observe(v)
+	}
+
+	for _, run := range synced.Runs {
+		// Both active states are always exported for every known workflow, so
+		// that the absence of active runs is visible.
+		for _, s := range []string{workflowStatusInProgress, workflowStatusQueued} {
+			k := activeKey{
+				Workflow: run.Name,
+				Status:   s,
+			}
+			if _, ok := active[k]; !ok {
+				active[k] = 0
+			}
+		}
+
+		if !run.RunStartedAt.IsZero() {
+			observe(queueDurations, run.Name, run.RunStartedAt.Sub(run.CreatedAt).Seconds())
+		}
+
+		if run.Status != workflowStatusCompleted {
+			k := activeKey{
+				Workflow: run.Name,
+				Status:   workflowActiveStatus(run.Status),
+			}
+			active[k] = active[k] + 1
+			continue
+		}
+
+		{
+			k := key{
+				Workflow:   run.Name,
+				Branch:     run.HeadBranch,
+				Event:      run.Event,
+				Conclusion: run.Conclusion,
+			}
+			runs[k] = runs[k] + 1
+		}
+
+		{
+			started := run.RunStartedAt
+			if started.IsZero() {
+				started = run.CreatedAt
+			}
+			observe(durations, run.Name, run.UpdatedAt.Sub(started).Seconds())
+		}
+	}
+
+	var metrics []prometheus.Metric
+
+	for k, v := range runs {
+		m := prometheus.MustNewConstMetric(
+			workflowRunsDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k.Workflow,
+			k.Branch,
+			k.Event,
+			k.Conclusion,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, v := range active {
+		m := prometheus.MustNewConstMetric(
+			workflowRunsActiveDesc,
+			prometheus.GaugeValue,
+			v,
+			r.Org,
+			r.Name,
+			k.Workflow,
+			k.Status,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, t := range synced.LastSuccess {
+		m := prometheus.MustNewConstMetric(
+			workflowLastSuccessAgeDesc,
+			prometheus.GaugeValue,
+			now.Sub(t).Seconds(),
+			r.Org,
+			r.Name,
+			k,
+		)
+		metrics = append(metrics, m)
+	}
+
+	for k, h := range durations {
+		metrics = append(metrics, h.Metric(workflowRunDurationDesc, r.Org, r.Name, k))
+	}
+
+	for k, h := range queueDurations {
+		metrics = append(metrics, h.Metric(workflowRunQueueDurationDesc, r.Org, r.Name, k))
+	}
+
+	return metrics
+}
+
+func workflowStoreKey(r Repository) string {
+	return "workflow/" + r.String()
+}
+
+// workflowActiveStatus maps the status of a workflow run which is not completed
+// to either in_progress or queued. Runs which are waiting for approval or
+// other runs, or were only requested, have not started yet and are thus
+// counted as queued.
+func workflowActiveStatus(status string) string {
+	if status == workflowStatusInProgress {
+		return workflowStatusInProgress
+	}
+
+	return workflowStatusQueued
+}
@@ -0,0 +1,207 @@
+package collector
+
+import (
+	"io/ioutil"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-github/github"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/giantswarm/github-exporter/service/store/memory"
+)
+
+func Test_Collector_Workflow_workflowMetrics(t *testing.T) {
+	now := time.Date(2019, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name           string
+		synced         *workflowSync
+		expectedRuns   map[string]float64
+		expectedActive map[string]float64
+		expectedAge    map[string]float64
+	}{
+		{
+			name: "case 0 completed runs are counted per branch, event and conclusion",
+			synced: &workflowSync{
+				Runs: map[int64]workflowRun{
+					1: {
+						Name:       "ci",
+						HeadBranch: "master",
+						Event:      "push",
+						Status:     "completed",
+						Conclusion: "success",
+					},
+					2: {
+						Name:       "ci",
+						HeadBranch: "master",
+						Event:      "push",
+						Status:     "completed",
+						Conclusion: "success",
+					},
+					3: {
+						Name:       "ci",
+						HeadBranch: "feature",
+						Event:      "pull_request",
+						Status:     "completed",
+						Conclusion: "failure",
+					},
+				},
+			},
+			expectedRuns: map[string]float64{
+				"ci/master/push/success":          2,
+				"ci/feature/pull_request/failure": 1,
+			},
+			expectedActive: map[string]float64{
+				"ci/in_progress": 0,
+				"ci/queued":      0,
+			},
+			expectedAge: map[string]float64{},
+		},
+		{
+			name: "case 1 runs which are not completed are counted as active",
+			synced: &workflowSync{
+				Runs: map[int64]workflowRun{
+					1: {
+						Name:   "ci",
+						Status: "in_progress",
+					},
+					2: {
+						Name:   "ci",
+						Status: "queued",
+					},
+					3: {
+						Name:   "release",
+						Status: "waiting",
+					},
+				},
+			},
+			expectedRuns: map[string]float64{},
+			expectedActive: map[string]float64{
+				"ci/in_progress":      1,
+				"ci/queued":           1,
+				"release/in_progress": 0,
+				"release/queued":      1,
+			},
+			expectedAge: map[string]float64{},
+		},
+		{
+			name: "case 2 latest successful runs are kept beyond the retention",
+			synced: &workflowSync{
+				LastSuccess: map[string]time.Time{
+					"ci": now.Add(-time.Hour),
+				},
+			},
+			expectedRuns:   map[string]float64{},
+			expectedActive: map[string]float64{},
+			expectedAge: map[string]float64{
+				"ci": 3600,
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c := &Workflow{
+				buckets: defaultWorkflowBuckets,
+			}
+
+			runs := map[string]float64{}
+			active := map[string]float64{}
+			age := map[string]float64{}
+			for _, m := range c.workflowMetrics(Repository{Org: "giantswarm", Name: "test"}, tc.synced, now) {
+				var d dto.Metric
+				err := m.Write(&d)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				labels := map[string]string{}
+				for _, l := range d.GetLabel() {
+					labels[l.GetName()] = l.GetValue()
+				}
+
+				switch m.Desc() {
+				case workflowRunsDesc:
+					runs[labels[labelWorkflow]+"/"+labels[labelBranch]+"/"+labels[labelEvent]+"/"+labels[labelConclusion]] = d.GetGauge().GetValue()
+				case workflowRunsActiveDesc:
+					active[labels[labelWorkflow]+"/"+labels[labelStatus]] = d.GetGauge().GetValue()
+				case workflowLastSuccessAgeDesc:
+					age[labels[labelWorkflow]] = d.GetGauge().GetValue()
+				}
+			}
+
+			if !cmp.Equal(runs, tc.expectedRuns) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(runs, tc.expectedRuns))
+			}
+			if !cmp.Equal(active, tc.expectedActive) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(active, tc.expectedActive))
+			}
+			if !cmp.Equal(age, tc.expectedAge) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(age, tc.expectedAge))
+			}
+		})
+	}
+}
+
+func Test_Collector_Workflow_NewWorkflow(t *testing.T) {
+	testCases := []struct {
+		name         string
+		buckets      []float64
+		errorMatcher func(error) bool
+	}{
+		{
+			name:         "case 0 default buckets",
+			buckets:      nil,
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 1 buckets in increasing order",
+			buckets:      []float64{60, 300, 900},
+			errorMatcher: nil,
+		},
+		{
+			name:         "case 2 buckets not in increasing order",
+			buckets:      []float64{300, 60, 900},
+			errorMatcher: IsInvalidConfig,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			s, err := memory.New(memory.Config{})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			c := WorkflowConfig{
+				Discovery:    &Discovery{},
+				GithubClient: github.NewClient(nil),
+				Logger:       logger,
+				Store:        s,
+
+				Buckets: tc.buckets,
+			}
+
+			_, err = NewWorkflow(c)
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+		})
+	}
+}
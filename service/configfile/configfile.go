@@ -0,0 +1,264 @@
+// Package configfile loads the configuration of the exporter from YAML or JSON
+// files. The structure of the files follows the command line flags, so the
+// flag --service.collector.interval is configured as follows.
+//
+//	service:
+//	  collector:
+//	    interval: 10m
+//
+// Flags holding JSON lists, which have to be marked using MarkLists, are
+// configured using plain lists in the file.
+package configfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	// listAnnotation is the annotation of flags holding JSON lists. See
+	// MarkLists.
+	listAnnotation = "configfile_list"
+	// reservedPrefix is the prefix of flags which configure how configuration
+	// is loaded. They cannot be set in config files.
+	reservedPrefix = "config."
+)
+
+// MarkLists marks the given flags as holding JSON lists of strings, which are
+// configured using plain lists in config files.
+func MarkLists(fs *pflag.FlagSet, names ...string) error {
+	for _, n := range names {
+		err := fs.SetAnnotation(n, listAnnotation, []string{"true"})
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	return nil
+}
+
+// Apply makes the given settings the config layer of the given viper. Flags
+// given on the command line and environment variables take precedence over the
+// settings. Applying settings again replaces all previously applied settings,
+// so that removed settings fall back to their defaults.
+func Apply(v *viper.Viper, settings map[string]interface{}) error {
+	b, err := json.Marshal(settings)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	v.SetConfigType("json")
+	err = v.ReadConfig(bytes.NewReader(b))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+// Read reads the config file at the given path and validates it against the
+// given flags. It returns the settings in the form viper expects them, i.e.
+// with lists encoded as JSON strings. Unknown fields and values of the wrong
+// type cause an invalidConfigError which contains the path of the offending
+// field.
+func Read(fs *pflag.FlagSet, path string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "config file %#q must be readable: %s", path, err)
+	}
+
+	settings, err := Parse(fs, b)
+	if err != nil {
+		return nil, microerror.Maskf(err, "config file %#q", path)
+	}
+
+	return settings, nil
+}
+
+// Parse parses the given YAML or JSON document. See Read.
+func Parse(fs *pflag.FlagSet, b []byte) (map[string]interface{}, error) {
+	var document interface{}
+	err := yaml.UnmarshalStrict(b, &document)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "must be valid YAML or JSON: %s", err)
+	}
+
+	// Empty documents do not configure anything.
+	if document == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	settings, err := parseObject(fs, "", document)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return settings, nil
+}
+
+func parseObject(fs *pflag.FlagSet, path string, value interface{}) (map[string]interface{}, error) {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok {
+		return nil, microerror.Maskf(invalidConfigError, "%s must be an object but got %s", fieldPath(path), typeName(value))
+	}
+
+	// Keys are sorted so that the reported error does not depend on the order
+	// of the map iteration.
+	var keys []string
+	values := map[string]interface{}{}
+	for k, v := range m {
+		s, ok := k.(string)
+		if !ok {
+			return nil, microerror.Maskf(invalidConfigError, "%s must only have string keys but got %#v", fieldPath(path), k)
+		}
+
+		// Keys are case insensitive just like viper keys.
+		s = strings.ToLower(s)
+		if _, ok := values[s]; ok {
+			return nil, microerror.Maskf(invalidConfigError, "%s must not be set twice", fieldPath(join(path, s)))
+		}
+
+		keys = append(keys, s)
+		values[s] = v
+	}
+	sort.Strings(keys)
+
+	settings := map[string]interface{}{}
+	for _, k := range keys {
+		p := join(path, k)
+
+		var err error
+		switch {
+		case strings.HasPrefix(p+".", reservedPrefix):
+			return nil, microerror.Maskf(invalidConfigError, "%s must not be set in config files", p)
+		case fs.Lookup(p) != nil:
+			settings[k], err = parseValue(fs.Lookup(p), values[k])
+		case hasPrefix(fs, p+"."):
+			settings[k], err = parseObject(fs, p, values[k])
+		default:
+			return nil, microerror.Maskf(invalidConfigError, "%s is not a known setting", p)
+		}
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	return settings, nil
+}
+
+func parseValue(f *pflag.Flag, value interface{}) (interface{}, error) {
+	switch f.Value.Type() {
+	case "bool":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+
+		return nil, microerror.Maskf(invalidConfigError, "%s must be a boolean but got %s", f.Name, typeName(value))
+	case "duration":
+		if v, ok := value.(string); ok {
+			_, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "%s must be a duration: %s", f.Name, err)
+			}
+
+			return v, nil
+		}
+
+		return nil, microerror.Maskf(invalidConfigError, "%s must be a duration, e.g. 5m, but got %s", f.Name, typeName(value))
+	case "int", "int64":
+		if v, ok := value.(int); ok {
+			return v, nil
+		}
+
+		return nil, microerror.Maskf(invalidConfigError, "%s must be an integer but got %s", f.Name, typeName(value))
+	case "string":
+		if _, ok := f.Annotations[listAnnotation]; ok {
+			l, ok := value.([]interface{})
+			if !ok {
+				return nil, microerror.Maskf(invalidConfigError, "%s must be a list but got %s", f.Name, typeName(value))
+			}
+
+			strs := []string{}
+			for i, e := range l {
+				s, ok := e.(string)
+				if !ok {
+					return nil, microerror.Maskf(invalidConfigError, "%s[%d] must be a string but got %s", f.Name, i, typeName(e))
+				}
+
+				strs = append(strs, s)
+			}
+
+			b, err := json.Marshal(strs)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			return string(b), nil
+		}
+
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+
+		return nil, microerror.Maskf(invalidConfigError, "%s must be a string but got %s", f.Name, typeName(value))
+	default:
+		return nil, microerror.Maskf(invalidConfigError, "%s must not be set in config files", f.Name)
+	}
+}
+
+func fieldPath(path string) string {
+	if path == "" {
+		return "document"
+	}
+
+	return path
+}
+
+func hasPrefix(fs *pflag.FlagSet, prefix string) bool {
+	var found bool
+
+	fs.VisitAll(func(f *pflag.Flag) {
+		if strings.HasPrefix(f.Name, prefix) {
+			found = true
+		}
+	})
+
+	return found
+}
+
+func join(path string, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int, int64, uint64, float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[interface{}]interface{}:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
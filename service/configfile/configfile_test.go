@@ -0,0 +1,213 @@
+package configfile
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/spf13/pflag"
+)
+
+func Test_ConfigFile_Parse(t *testing.T) {
+	testCases := []struct {
+		name             string
+		document         string
+		expectedSettings map[string]interface{}
+		errorMatcher     func(error) bool
+		expectedMessage  string
+	}{
+		{
+			name:             "case 0 empty document",
+			document:         "",
+			expectedSettings: map[string]interface{}{},
+		},
+		{
+			name: "case 1 YAML document",
+			document: `
+service:
+  collector:
+    interval: 10m
+    issue:
+      customLabels:
+      - postmortem AND team/*
+      - kind/bug
+    pullrequest:
+      enabled: true
+  github:
+    cache:
+      maxbytes: 100
+`,
+			expectedSettings: map[string]interface{}{
+				"service": map[string]interface{}{
+					"collector": map[string]interface{}{
+						"interval": "10m",
+						"issue": map[string]interface{}{
+							"customlabels": `["postmortem AND team/*","kind/bug"]`,
+						},
+						"pullrequest": map[string]interface{}{
+							"enabled": true,
+						},
+					},
+					"github": map[string]interface{}{
+						"cache": map[string]interface{}{
+							"maxbytes": 100,
+						},
+					},
+				},
+			},
+		},
+		{
+			name:     "case 2 JSON document",
+			document: `{"service": {"collector": {"repositories": ["giantswarm/github-exporter"]}}}`,
+			expectedSettings: map[string]interface{}{
+				"service": map[string]interface{}{
+					"collector": map[string]interface{}{
+						"repositories": `["giantswarm/github-exporter"]`,
+					},
+				},
+			},
+		},
+		{
+			name:     "case 3 empty list",
+			document: `{"service": {"collector": {"repositories": []}}}`,
+			expectedSettings: map[string]interface{}{
+				"service": map[string]interface{}{
+					"collector": map[string]interface{}{
+						"repositories": `[]`,
+					},
+				},
+			},
+		},
+		{
+			name: "case 4 unknown setting",
+			document: `
+service:
+  collector:
+    intervall: 10m
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector.intervall is not a known setting",
+		},
+		{
+			name: "case 5 object instead of value",
+			document: `
+service:
+  collector: true
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector must be an object but got boolean",
+		},
+		{
+			name: "case 6 invalid duration",
+			document: `
+service:
+  collector:
+    interval: 10 minutes
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector.interval must be a duration",
+		},
+		{
+			name: "case 7 string instead of list",
+			document: `
+service:
+  collector:
+    repositories: giantswarm/github-exporter
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector.repositories must be a list but got string",
+		},
+		{
+			name: "case 8 invalid list element",
+			document: `
+service:
+  collector:
+    repositories:
+    - giantswarm/github-exporter
+    - org: giantswarm
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector.repositories[1] must be a string but got object",
+		},
+		{
+			name: "case 9 string instead of integer",
+			document: `
+service:
+  github:
+    cache:
+      maxbytes: many
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.github.cache.maxbytes must be an integer but got string",
+		},
+		{
+			name: "case 10 reserved setting",
+			document: `
+config: other.yaml
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "config must not be set in config files",
+		},
+		{
+			name:            "case 11 invalid syntax",
+			document:        `{"service": `,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "must be valid YAML or JSON",
+		},
+		{
+			name: "case 12 duplicate setting",
+			document: `
+service:
+  collector:
+    interval: 10m
+    Interval: 5m
+`,
+			errorMatcher:    IsInvalidConfig,
+			expectedMessage: "service.collector.interval must not be set twice",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.String("config", "", "")
+			fs.Duration("service.collector.interval", 5*time.Minute, "")
+			fs.String("service.collector.issue.customlabels", "[]", "")
+			fs.Bool("service.collector.pullrequest.enabled", false, "")
+			fs.String("service.collector.repositories", `["giantswarm/giantswarm"]`, "")
+			fs.Int("service.github.cache.maxbytes", 10000, "")
+			fs.String("service.github.auth.token", "", "")
+
+			err := MarkLists(fs, "service.collector.issue.customlabels", "service.collector.repositories")
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			settings, err := Parse(fs, []byte(tc.document))
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if tc.errorMatcher != nil {
+				if !strings.Contains(err.Error(), tc.expectedMessage) {
+					t.Fatalf("expected error message to contain %#q got %#q", tc.expectedMessage, err.Error())
+				}
+				return
+			}
+
+			if !cmp.Equal(settings, tc.expectedSettings) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(settings, tc.expectedSettings))
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+// Package auth provides token sources to authenticate against the Github API.
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"golang.org/x/oauth2"
+)
+
+const (
+	// DefaultBaseURL is the base URL of the public Github API.
+	DefaultBaseURL = "https://api.github.com/"
+	// DefaultTimeout is the default timeout of requests of installation tokens.
+	DefaultTimeout = 30 * time.Second
+)
+
+const (
+	// jwtLifetime is the lifetime of the JWTs used to request installation
+	// tokens. Github accepts at most ten minutes.
+	jwtLifetime = 9 * time.Minute
+	// jwtClockSkew is subtracted from the JWT issue time to account for clock
+	// drift between the exporter and Github.
+	jwtClockSkew = time.Minute
+	// refreshBefore is subtracted from the expiry of installation tokens, so
+	// that they are refreshed before they actually expire.
+	refreshBefore = 5 * time.Minute
+)
+
+type AppConfig struct {
+	// HTTPClient is used to request installation tokens. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+
+	// AppID is the ID of the Github App.
+	AppID int64
+	// BaseURL is the base URL of the Github API. Defaults to DefaultBaseURL.
+	BaseURL string
+	// InstallationID is the ID of the Github App's installation of which
+	// installation tokens are requested.
+	InstallationID int64
+	// PrivateKey is the PEM encoded private key of the Github App.
+	PrivateKey []byte
+	// Timeout limits the time requesting an installation token may take, since
+	// the token source interface does not allow passing a context. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+}
+
+// App is an oauth2.TokenSource which mints installation tokens of a Github
+// App. Each call to Token requests a new installation token, so App should be
+// wrapped using oauth2.ReuseTokenSource. The returned tokens expire early
+// enough to be refreshed before Github rejects them.
+type App struct {
+	httpClient *http.Client
+	privateKey *rsa.PrivateKey
+
+	appID          int64
+	baseURL        string
+	installationID int64
+	timeout        time.Duration
+}
+
+func NewApp(config AppConfig) (*App, error) {
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	if config.AppID <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.AppID must be greater than 0", config)
+	}
+	if config.BaseURL == "" {
+		config.BaseURL = DefaultBaseURL
+	}
+	if config.InstallationID <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.InstallationID must be greater than 0", config)
+	}
+	if len(config.PrivateKey) == 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.PrivateKey must not be empty", config)
+	}
+	if config.Timeout == 0 {
+		config.Timeout = DefaultTimeout
+	}
+	if config.Timeout < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Timeout must not be negative", config)
+	}
+
+	privateKey, err := parsePrivateKey(config.PrivateKey)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	a := &App{
+		httpClient: config.HTTPClient,
+		privateKey: privateKey,
+
+		appID:          config.AppID,
+		baseURL:        strings.TrimSuffix(config.BaseURL, "/") + "/",
+		installationID: config.InstallationID,
+		timeout:        config.Timeout,
+	}
+
+	return a, nil
+}
+
+// Token requests a new installation token from the Github API.
+func (a *App) Token() (*oauth2.Token, error) {
+	jwt, err := a.jwt(time.Now())
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	u := fmt.Sprintf("%sapp/installations/%d/access_tokens", a.baseURL, a.installationID)
+	req, err := http.NewRequest("POST", u, nil)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/vnd.github.machine-man-preview+json")
+	req.Header.Set("Authorization", "Bearer "+jwt)
+
+	res, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, microerror.Maskf(executionFailedError, "requesting installation token failed: %s", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return nil, microerror.Maskf(executionFailedError, "requesting installation token failed with status code %d", res.StatusCode)
+	}
+
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	t := &oauth2.Token{
+		AccessToken: body.Token,
+		Expiry:      body.ExpiresAt.Add(-refreshBefore),
+	}
+
+	return t, nil
+}
+
+// jwt returns a JWT signed with the Github App's private key, which
+// authenticates the Github App itself. See also
+// https://developer.github.com/apps/building-github-apps/authenticating-with-github-apps/#authenticating-as-a-github-app.
+func (a *App) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{
+		"alg": "RS256",
+		"typ": "JWT",
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	claims, err := json.Marshal(map[string]int64{
+		"exp": now.Add(jwtLifetime).Unix(),
+		"iat": now.Add(-jwtClockSkew).Unix(),
+		"iss": a.appID,
+	})
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	hashed := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, a.privateKey, crypto.SHA256, hashed[:])
+	if err != nil {
+		return "", microerror.Mask(err)
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, microerror.Maskf(invalidConfigError, "private key must be PEM encoded")
+	}
+
+	// Github hands out PKCS#1 encoded keys, but keys converted to PKCS#8 are
+	// supported as well.
+	{
+		k, err := x509.ParsePKCS1PrivateKey(block.Bytes)
+		if err == nil {
+			return k, nil
+		}
+	}
+
+	{
+		k, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "private key must be a RSA key: %s", err)
+		}
+
+		rsaKey, ok := k.(*rsa.PrivateKey)
+		if !ok {
+			return nil, microerror.Maskf(invalidConfigError, "private key must be a RSA key")
+		}
+
+		return rsaKey, nil
+	}
+}
@@ -0,0 +1,175 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Auth_App_Token(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
+		Type:  "RSA PRIVATE KEY",
+		Bytes: x509.MarshalPKCS1PrivateKey(privateKey),
+	})
+
+	expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+
+	testCases := []struct {
+		name               string
+		statusCode         int
+		delay              time.Duration
+		timeout            time.Duration
+		expectedToken      string
+		expectedExpiry     time.Time
+		errorMatcher       func(error) bool
+		expectedIssuer     float64
+		expectedRequestURL string
+	}{
+		{
+			name:               "case 0 installation token is returned",
+			statusCode:         http.StatusCreated,
+			expectedToken:      "v1.installation-token",
+			expectedExpiry:     expiresAt.Add(-refreshBefore),
+			errorMatcher:       nil,
+			expectedIssuer:     23,
+			expectedRequestURL: "/app/installations/42/access_tokens",
+		},
+		{
+			name:               "case 1 rejected request causes error",
+			statusCode:         http.StatusUnauthorized,
+			expectedToken:      "",
+			errorMatcher:       IsExecutionFailed,
+			expectedIssuer:     23,
+			expectedRequestURL: "/app/installations/42/access_tokens",
+		},
+		{
+			name:               "case 2 request exceeding the timeout causes error",
+			statusCode:         http.StatusCreated,
+			delay:              time.Second,
+			timeout:            50 * time.Millisecond,
+			expectedToken:      "",
+			errorMatcher:       IsExecutionFailed,
+			expectedIssuer:     23,
+			expectedRequestURL: "/app/installations/42/access_tokens",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var requestURL string
+			var issuer float64
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestURL = r.URL.Path
+				issuer = verifyJWT(t, &privateKey.PublicKey, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+
+				select {
+				case <-time.After(tc.delay):
+				case <-r.Context().Done():
+					return
+				}
+
+				w.WriteHeader(tc.statusCode)
+				json.NewEncoder(w).Encode(map[string]interface{}{
+					"token":      "v1.installation-token",
+					"expires_at": expiresAt.Format(time.RFC3339),
+				})
+			}))
+			defer server.Close()
+
+			var app *App
+			{
+				c := AppConfig{
+					AppID:          23,
+					BaseURL:        server.URL,
+					InstallationID: 42,
+					PrivateKey:     privateKeyPEM,
+					Timeout:        tc.timeout,
+				}
+
+				app, err = NewApp(c)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			token, err := app.Token()
+
+			switch {
+			case err == nil && tc.errorMatcher == nil:
+				// correct; carry on
+			case err != nil && tc.errorMatcher == nil:
+				t.Fatalf("error == %#v, want nil", err)
+			case err == nil && tc.errorMatcher != nil:
+				t.Fatalf("error == nil, want non-nil")
+			case !tc.errorMatcher(err):
+				t.Fatalf("error == %#v, want matching", err)
+			}
+
+			if requestURL != tc.expectedRequestURL {
+				t.Fatalf("\n\n%s\n", cmp.Diff(requestURL, tc.expectedRequestURL))
+			}
+			if issuer != tc.expectedIssuer {
+				t.Fatalf("\n\n%s\n", cmp.Diff(issuer, tc.expectedIssuer))
+			}
+
+			if tc.errorMatcher != nil {
+				return
+			}
+
+			if token.AccessToken != tc.expectedToken {
+				t.Fatalf("\n\n%s\n", cmp.Diff(token.AccessToken, tc.expectedToken))
+			}
+			if !token.Expiry.Equal(tc.expectedExpiry) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(token.Expiry.String(), tc.expectedExpiry.String()))
+			}
+		})
+	}
+}
+
+// verifyJWT verifies the signature of the given JWT and returns its issuer.
+func verifyJWT(t *testing.T, publicKey *rsa.PublicKey, jwt string) float64 {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("expected JWT to have 3 parts got %d", len(parts))
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	hashed := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	err = rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hashed[:], signature)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+	var claims map[string]float64
+	err = json.Unmarshal(b, &claims)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	return claims["iss"]
+}
@@ -0,0 +1,246 @@
+package transport
+
+import (
+	"bytes"
+	"container/list"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	labelResult = "result"
+)
+
+const (
+	resultHit  = "hit"
+	resultMiss = "miss"
+)
+
+var (
+	cacheRequestsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "cache_requests_total"),
+		"Number of cacheable Github API requests per cache result.",
+		[]string{
+			labelResult,
+		},
+		nil,
+	)
+)
+
+type CacheConfig struct {
+	Transport http.RoundTripper
+
+	// MaxBytes is the maximum total size of the bodies of the responses kept
+	// in the cache. The least recently used responses are evicted when the
+	// limit is exceeded. Responses larger than the limit are not cached.
+	MaxBytes int
+}
+
+// Cache is a http.RoundTripper which sends conditional requests for GET
+// requests of which a response with ETag or Last-Modified header was received
+// before. Github answers those with 304 Not Modified if nothing changed, which
+// does not count against the rate limit. The cached response is then returned
+// to the caller instead.
+type Cache struct {
+	transport http.RoundTripper
+
+	// entries maps the cache keys to their elements in lru, which holds the
+	// entries ordered from the most to the least recently used one.
+	entries  map[string]*list.Element
+	lru      *list.List
+	mutex    sync.Mutex
+	requests map[string]float64
+	size     int
+
+	maxBytes int
+}
+
+// cacheEntry is a cached response. It must not be modified once added to the
+// cache, since it is used without holding the mutex.
+type cacheEntry struct {
+	Body         []byte
+	ETag         string
+	Header       http.Header
+	Key          string
+	LastModified string
+}
+
+func NewCache(config CacheConfig) (*Cache, error) {
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	if config.MaxBytes <= 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.MaxBytes must be greater than 0", config)
+	}
+
+	c := &Cache{
+		transport: config.Transport,
+
+		entries:  map[string]*list.Element{},
+		lru:      list.New(),
+		mutex:    sync.Mutex{},
+		requests: map[string]float64{},
+		size:     0,
+
+		maxBytes: config.MaxBytes,
+	}
+
+	return c, nil
+}
+
+func (c *Cache) Collect(ch chan<- prometheus.Metric) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for k, v := range c.requests {
+		ch <- prometheus.MustNewConstMetric(
+			cacheRequestsDesc,
+			prometheus.CounterValue,
+			v,
+			k,
+		)
+	}
+
+	return nil
+}
+
+func (c *Cache) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- cacheRequestsDesc
+	return nil
+}
+
+func (c *Cache) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" || req.Header.Get("Range") != "" {
+		return c.transport.RoundTrip(req)
+	}
+
+	key := req.URL.String() + " " + req.Header.Get("Accept")
+
+	var entry *cacheEntry
+	c.mutex.Lock()
+	element, ok := c.entries[key]
+	if ok {
+		entry = element.Value.(*cacheEntry)
+	}
+	c.mutex.Unlock()
+
+	// The request given to a http.RoundTripper must not be modified, so the
+	// conditional headers are set on a copy.
+	if ok {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	res, err := c.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if ok && res.StatusCode == http.StatusNotModified {
+		res.Body.Close()
+
+		// The headers of the fresh response, e.g. the rate limit, take
+		// precedence over the cached ones.
+		header := cloneHeader(entry.Header)
+		for k, v := range res.Header {
+			header[k] = v
+		}
+
+		// The entry might have been evicted in the meantime, in which case
+		// moving it is a no-op.
+		c.mutex.Lock()
+		c.lru.MoveToFront(element)
+		c.requests[resultHit]++
+		c.mutex.Unlock()
+
+		cached := &http.Response{
+			Body:          ioutil.NopCloser(bytes.NewReader(entry.Body)),
+			ContentLength: int64(len(entry.Body)),
+			Header:        header,
+			Proto:         res.Proto,
+			ProtoMajor:    res.ProtoMajor,
+			ProtoMinor:    res.ProtoMinor,
+			Request:       res.Request,
+			Status:        "200 OK",
+			StatusCode:    http.StatusOK,
+		}
+
+		return cached, nil
+	}
+
+	c.mutex.Lock()
+	c.requests[resultMiss]++
+	c.mutex.Unlock()
+
+	etag := res.Header.Get("ETag")
+	lastModified := res.Header.Get("Last-Modified")
+	if res.StatusCode != http.StatusOK || (etag == "" && lastModified == "") {
+		return res, nil
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	c.mutex.Lock()
+	c.add(&cacheEntry{
+		Body:         body,
+		ETag:         etag,
+		Header:       cloneHeader(res.Header),
+		Key:          key,
+		LastModified: lastModified,
+	})
+	c.mutex.Unlock()
+
+	return res, nil
+}
+
+// add adds the given entry as the most recently used one, replacing any entry
+// with the same key, and evicts the least recently used entries until the
+// cache does not exceed its maximum size anymore. Entries larger than the
+// maximum size are not added at all. It must be called with the mutex held.
+func (c *Cache) add(entry *cacheEntry) {
+	if element, ok := c.entries[entry.Key]; ok {
+		c.remove(element)
+	}
+	if len(entry.Body) > c.maxBytes {
+		return
+	}
+
+	c.entries[entry.Key] = c.lru.PushFront(entry)
+	c.size += len(entry.Body)
+
+	for c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove removes the given element from the cache. It must be called with the
+// mutex held.
+func (c *Cache) remove(element *list.Element) {
+	entry := c.lru.Remove(element).(*cacheEntry)
+	delete(c.entries, entry.Key)
+	c.size -= len(entry.Body)
+}
+
+func cloneHeader(h http.Header) http.Header {
+	c := make(http.Header, len(h))
+	for k, v := range h {
+		c[k] = append([]string(nil), v...)
+	}
+
+	return c
+}
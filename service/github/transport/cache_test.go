@@ -0,0 +1,197 @@
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Transport_Cache_RoundTrip(t *testing.T) {
+	testCases := []struct {
+		name             string
+		header           map[string]string
+		expectedRequests map[string]float64
+		expectedServed   int
+	}{
+		{
+			name: "case 0 responses with ETag are served from the cache",
+			header: map[string]string{
+				"ETag": `"abc"`,
+			},
+			expectedRequests: map[string]float64{
+				resultHit:  2,
+				resultMiss: 1,
+			},
+			expectedServed: 1,
+		},
+		{
+			name: "case 1 responses with Last-Modified are served from the cache",
+			header: map[string]string{
+				"Last-Modified": "Mon, 02 Jan 2006 15:04:05 GMT",
+			},
+			expectedRequests: map[string]float64{
+				resultHit:  2,
+				resultMiss: 1,
+			},
+			expectedServed: 1,
+		},
+		{
+			name:   "case 2 responses without validators are not cached",
+			header: map[string]string{},
+			expectedRequests: map[string]float64{
+				resultMiss: 3,
+			},
+			expectedServed: 3,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var served int
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for k, v := range tc.header {
+					w.Header().Set(k, v)
+				}
+
+				if r.Header.Get("If-None-Match") == tc.header["ETag"] && tc.header["ETag"] != "" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+				if r.Header.Get("If-Modified-Since") == tc.header["Last-Modified"] && tc.header["Last-Modified"] != "" {
+					w.WriteHeader(http.StatusNotModified)
+					return
+				}
+
+				served++
+				w.Write([]byte("body"))
+			}))
+			defer server.Close()
+
+			var err error
+
+			var c *Cache
+			{
+				config := CacheConfig{
+					MaxBytes: 1024,
+				}
+
+				c, err = NewCache(config)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			client := &http.Client{
+				Transport: c,
+			}
+
+			for j := 0; j < 3; j++ {
+				res, err := client.Get(server.URL)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				body, err := ioutil.ReadAll(res.Body)
+				res.Body.Close()
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+
+				if res.StatusCode != http.StatusOK {
+					t.Fatalf("\n\n%s\n", cmp.Diff(res.StatusCode, http.StatusOK))
+				}
+				if string(body) != "body" {
+					t.Fatalf("\n\n%s\n", cmp.Diff(string(body), "body"))
+				}
+			}
+
+			if served != tc.expectedServed {
+				t.Fatalf("\n\n%s\n", cmp.Diff(served, tc.expectedServed))
+			}
+			if !cmp.Equal(c.requests, tc.expectedRequests) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.requests, tc.expectedRequests))
+			}
+		})
+	}
+}
+
+func Test_Transport_Cache_add(t *testing.T) {
+	// Steps of the form key=body add an entry, steps of the form key use the
+	// entry with the given key.
+	testCases := []struct {
+		name         string
+		steps        []string
+		expectedKeys []string
+		expectedSize int
+	}{
+		{
+			name:         "case 0 entries within the maximum size are kept",
+			steps:        []string{"a=1234", "b=1234"},
+			expectedKeys: []string{"b", "a"},
+			expectedSize: 8,
+		},
+		{
+			name:         "case 1 least recently added entry is evicted",
+			steps:        []string{"a=1234", "b=1234", "c=1234"},
+			expectedKeys: []string{"c", "b"},
+			expectedSize: 8,
+		},
+		{
+			name:         "case 2 least recently used entry is evicted",
+			steps:        []string{"a=1234", "b=1234", "a", "c=1234"},
+			expectedKeys: []string{"c", "a"},
+			expectedSize: 8,
+		},
+		{
+			name:         "case 3 replaced entry is only counted once",
+			steps:        []string{"a=1234", "b=1234", "a=12"},
+			expectedKeys: []string{"a", "b"},
+			expectedSize: 6,
+		},
+		{
+			name:         "case 4 entry larger than the maximum size is not cached",
+			steps:        []string{"a=1234", "b=12345678901"},
+			expectedKeys: []string{"a"},
+			expectedSize: 4,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			c, err := NewCache(CacheConfig{MaxBytes: 10})
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			for _, s := range tc.steps {
+				split := strings.SplitN(s, "=", 2)
+				if len(split) == 1 {
+					c.lru.MoveToFront(c.entries[split[0]])
+					continue
+				}
+				c.add(&cacheEntry{Body: []byte(split[1]), Key: split[0]})
+			}
+
+			var keys []string
+			for e := c.lru.Front(); e != nil; e = e.Next() {
+				keys = append(keys, e.Value.(*cacheEntry).Key)
+			}
+
+			if !cmp.Equal(keys, tc.expectedKeys) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(keys, tc.expectedKeys))
+			}
+			if len(c.entries) != len(tc.expectedKeys) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(len(c.entries), len(tc.expectedKeys)))
+			}
+			if c.size != tc.expectedSize {
+				t.Fatalf("\n\n%s\n", cmp.Diff(c.size, tc.expectedSize))
+			}
+		})
+	}
+}
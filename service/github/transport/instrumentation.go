@@ -0,0 +1,140 @@
+package transport
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	labelEndpoint = "endpoint"
+	labelStatus   = "status"
+)
+
+var (
+	requestsDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "requests_total"),
+		"Number of requests sent to the Github API per endpoint and status code.",
+		[]string{
+			labelEndpoint,
+			labelStatus,
+		},
+		nil,
+	)
+)
+
+type InstrumentationConfig struct {
+	Transport http.RoundTripper
+}
+
+// Instrumentation is a http.RoundTripper which counts the requests sent to the
+// Github API per endpoint and response status code. Requests failing without
+// response are counted with the status "error".
+type Instrumentation struct {
+	transport http.RoundTripper
+
+	mutex    sync.Mutex
+	requests map[requestKey]float64
+}
+
+type requestKey struct {
+	Endpoint string
+	Status   string
+}
+
+func NewInstrumentation(config InstrumentationConfig) (*Instrumentation, error) {
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	i := &Instrumentation{
+		transport: config.Transport,
+
+		mutex:    sync.Mutex{},
+		requests: map[requestKey]float64{},
+	}
+
+	return i, nil
+}
+
+func (i *Instrumentation) Collect(ch chan<- prometheus.Metric) error {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	for k, v := range i.requests {
+		ch <- prometheus.MustNewConstMetric(
+			requestsDesc,
+			prometheus.CounterValue,
+			v,
+			k.Endpoint,
+			k.Status,
+		)
+	}
+
+	return nil
+}
+
+func (i *Instrumentation) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- requestsDesc
+	return nil
+}
+
+func (i *Instrumentation) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := i.transport.RoundTrip(req)
+
+	k := requestKey{
+		Endpoint: endpoint(req.URL.Path),
+		Status:   "error",
+	}
+	if err == nil {
+		k.Status = strconv.Itoa(res.StatusCode)
+	}
+
+	i.mutex.Lock()
+	i.requests[k]++
+	i.mutex.Unlock()
+
+	return res, err
+}
+
+// endpoint replaces the variable segments of the given request path with
+// placeholders, so that the cardinality of the endpoint label stays bounded,
+// e.g. /repos/giantswarm/github-exporter/issues/23/comments becomes
+// /repos/:owner/:repo/issues/:number/comments.
+func endpoint(p string) string {
+	segments := strings.Split(p, "/")
+
+	for i, s := range segments {
+		if s == "" {
+			continue
+		}
+
+		if _, err := strconv.ParseInt(s, 10, 64); err == nil {
+			segments[i] = ":number"
+			continue
+		}
+
+		if i < 1 {
+			continue
+		}
+
+		switch segments[i-1] {
+		case "commits":
+			segments[i] = ":ref"
+		case "orgs":
+			segments[i] = ":org"
+		case "repos":
+			segments[i] = ":owner"
+			if i+1 < len(segments) && segments[i+1] != "" {
+				segments[i+1] = ":repo"
+			}
+		case "users":
+			segments[i] = ":user"
+		}
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,62 @@
+package transport
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Transport_Instrumentation_endpoint(t *testing.T) {
+	testCases := []struct {
+		name           string
+		path           string
+		expectedResult string
+	}{
+		{
+			name:           "case 0 path without variable segments",
+			path:           "/rate_limit",
+			expectedResult: "/rate_limit",
+		},
+		{
+			name:           "case 1 repository path",
+			path:           "/repos/giantswarm/github-exporter/issues",
+			expectedResult: "/repos/:owner/:repo/issues",
+		},
+		{
+			name:           "case 2 repository path with number",
+			path:           "/repos/giantswarm/github-exporter/issues/23/comments",
+			expectedResult: "/repos/:owner/:repo/issues/:number/comments",
+		},
+		{
+			name:           "case 3 organization path",
+			path:           "/orgs/giantswarm/repos",
+			expectedResult: "/orgs/:org/repos",
+		},
+		{
+			name:           "case 4 commit path",
+			path:           "/repos/giantswarm/github-exporter/commits/master/status",
+			expectedResult: "/repos/:owner/:repo/commits/:ref/status",
+		},
+		{
+			name:           "case 5 Github Enterprise Server path",
+			path:           "/api/v3/repos/giantswarm/github-exporter/pulls/42/reviews",
+			expectedResult: "/api/v3/repos/:owner/:repo/pulls/:number/reviews",
+		},
+		{
+			name:           "case 6 installation path",
+			path:           "/app/installations/12345/access_tokens",
+			expectedResult: "/app/installations/:number/access_tokens",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			result := endpoint(tc.path)
+
+			if result != tc.expectedResult {
+				t.Fatalf("\n\n%s\n", cmp.Diff(result, tc.expectedResult))
+			}
+		})
+	}
+}
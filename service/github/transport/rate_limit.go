@@ -0,0 +1,258 @@
+package transport
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	headerRateLimit     = "X-RateLimit-Limit"
+	headerRateRemaining = "X-RateLimit-Remaining"
+	headerRateReset     = "X-RateLimit-Reset"
+	headerRetryAfter    = "Retry-After"
+)
+
+var (
+	rateLimitLimitDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "rate_limit_limit"),
+		"Maximum number of Github API requests per hour.",
+		[]string{
+			labelToken,
+		},
+		nil,
+	)
+	rateLimitRemainingDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "rate_limit_remaining"),
+		"Remaining number of Github API requests in the current rate limit window.",
+		[]string{
+			labelToken,
+		},
+		nil,
+	)
+	rateLimitResetDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, subsystem, "rate_limit_reset_timestamp_seconds"),
+		"Unix timestamp at which the current rate limit window resets.",
+		[]string{
+			labelToken,
+		},
+		nil,
+	)
+)
+
+type RateLimitConfig struct {
+	Logger    micrologger.Logger
+	Transport http.RoundTripper
+
+	// Threshold is the number of remaining requests below which requests are
+	// paused until the rate limit window resets. It only applies if it is below
+	// the reported limit, e.g. not for anonymous requests, which are limited to
+	// 60 requests per hour. Otherwise requests are paused once the remaining
+	// quota is exhausted.
+	Threshold int
+	// Token identifies the credentials the rate limit applies to. It is used as
+	// label value and must not contain any secret.
+	Token string
+}
+
+// RateLimit is a http.RoundTripper which tracks the rate limit of the Github
+// API. Requests are paused when the remaining quota drops below the configured
+// threshold until the rate limit window resets. Secondary rate limit responses
+// carrying a Retry-After header pause requests for the given time and are
+// retried once.
+type RateLimit struct {
+	logger    micrologger.Logger
+	transport http.RoundTripper
+
+	limit       int
+	mutex       sync.RWMutex
+	pausedUntil time.Time
+	remaining   int
+	reset       time.Time
+
+	threshold int
+	token     string
+}
+
+func NewRateLimit(config RateLimitConfig) (*RateLimit, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.Transport == nil {
+		config.Transport = http.DefaultTransport
+	}
+
+	if config.Threshold < 0 {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Threshold must not be negative", config)
+	}
+	if config.Token == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Token must not be empty", config)
+	}
+
+	r := &RateLimit{
+		logger:    config.Logger,
+		transport: config.Transport,
+
+		limit:       -1,
+		mutex:       sync.RWMutex{},
+		pausedUntil: time.Time{},
+		remaining:   -1,
+		reset:       time.Time{},
+
+		threshold: config.Threshold,
+		token:     config.Token,
+	}
+
+	return r, nil
+}
+
+func (r *RateLimit) Collect(ch chan<- prometheus.Metric) error {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	// As long as no response was received there is nothing we know about the
+	// rate limit.
+	if r.limit < 0 {
+		return nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(
+		rateLimitLimitDesc,
+		prometheus.GaugeValue,
+		float64(r.limit),
+		r.token,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		rateLimitRemainingDesc,
+		prometheus.GaugeValue,
+		float64(r.remaining),
+		r.token,
+	)
+	ch <- prometheus.MustNewConstMetric(
+		rateLimitResetDesc,
+		prometheus.GaugeValue,
+		float64(r.reset.Unix()),
+		r.token,
+	)
+
+	return nil
+}
+
+func (r *RateLimit) Describe(ch chan<- *prometheus.Desc) error {
+	ch <- rateLimitLimitDesc
+	ch <- rateLimitRemainingDesc
+	ch <- rateLimitResetDesc
+	return nil
+}
+
+func (r *RateLimit) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Requests with a body can only be retried if the body can be obtained
+	// again.
+	retryable := req.Body == nil || req.GetBody != nil
+
+	for attempt := 0; ; attempt++ {
+		err := r.pause(req)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		if attempt > 0 && req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+		}
+
+		res, err := r.transport.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+
+		secondary := r.update(res, time.Now())
+		if !secondary || !retryable || attempt > 0 {
+			return res, nil
+		}
+
+		res.Body.Close()
+	}
+}
+
+// pause blocks until requests are allowed again or the request's context is
+// done.
+func (r *RateLimit) pause(req *http.Request) error {
+	d := r.wait(time.Now())
+	if d <= 0 {
+		return nil
+	}
+
+	r.logger.LogCtx(req.Context(), "level", "info", "message", fmt.Sprintf("pausing Github API requests for %s due to rate limiting", d))
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-req.Context().Done():
+		return microerror.Mask(req.Context().Err())
+	case <-t.C:
+		return nil
+	}
+}
+
+// update tracks the rate limit given by the response headers. It returns true
+// if the response is a secondary rate limit response, which should be retried
+// after the time given by the Retry-After header.
+func (r *RateLimit) update(res *http.Response, now time.Time) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if v, err := strconv.Atoi(res.Header.Get(headerRateLimit)); err == nil {
+		r.limit = v
+	}
+	if v, err := strconv.Atoi(res.Header.Get(headerRateRemaining)); err == nil {
+		r.remaining = v
+	}
+	if v, err := strconv.ParseInt(res.Header.Get(headerRateReset), 10, 64); err == nil {
+		r.reset = time.Unix(v, 0)
+	}
+
+	if res.StatusCode != http.StatusForbidden && res.StatusCode != http.StatusTooManyRequests {
+		return false
+	}
+
+	v, err := strconv.Atoi(res.Header.Get(headerRetryAfter))
+	if err != nil {
+		return false
+	}
+
+	r.pausedUntil = now.Add(time.Duration(v) * time.Second)
+
+	return true
+}
+
+// wait returns how long requests have to be paused at the given time.
+func (r *RateLimit) wait(now time.Time) time.Duration {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var d time.Duration
+
+	if r.pausedUntil.After(now) {
+		d = r.pausedUntil.Sub(now)
+	}
+
+	// A threshold at or above the limit would pause requests as soon as the
+	// rate limit window starts, so it is ignored in this case.
+	belowThreshold := r.threshold < r.limit && r.remaining < r.threshold
+	exhausted := r.remaining >= 0 && (r.remaining == 0 || belowThreshold)
+	if exhausted && r.reset.After(now) && r.reset.Sub(now) > d {
+		d = r.reset.Sub(now)
+	}
+
+	return d
+}
@@ -0,0 +1,163 @@
+package transport
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Transport_RateLimit_wait(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+
+	testCases := []struct {
+		name              string
+		statusCode        int
+		header            map[string]string
+		expectedSecondary bool
+		expectedWait      time.Duration
+	}{
+		{
+			name:              "case 0 no rate limit headers",
+			statusCode:        http.StatusOK,
+			header:            map[string]string{},
+			expectedSecondary: false,
+			expectedWait:      0,
+		},
+		{
+			name:       "case 1 remaining quota above threshold",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				headerRateLimit:     "5000",
+				headerRateRemaining: "4000",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      0,
+		},
+		{
+			name:       "case 2 remaining quota below threshold",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				headerRateLimit:     "5000",
+				headerRateRemaining: "99",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      30 * time.Minute,
+		},
+		{
+			name:       "case 3 remaining quota below threshold but reset in the past",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				headerRateLimit:     "5000",
+				headerRateRemaining: "99",
+				headerRateReset:     strconv.FormatInt(now.Add(-time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      0,
+		},
+		{
+			name:       "case 4 secondary rate limit",
+			statusCode: http.StatusForbidden,
+			header: map[string]string{
+				headerRateLimit:     "5000",
+				headerRateRemaining: "4000",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+				headerRetryAfter:    "60",
+			},
+			expectedSecondary: true,
+			expectedWait:      time.Minute,
+		},
+		{
+			name:       "case 5 forbidden without retry after",
+			statusCode: http.StatusForbidden,
+			header: map[string]string{
+				headerRateLimit:     "5000",
+				headerRateRemaining: "4000",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      0,
+		},
+		{
+			name:       "case 6 limit below threshold",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				headerRateLimit:     "60",
+				headerRateRemaining: "59",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      0,
+		},
+		{
+			name:       "case 7 limit below threshold and remaining quota exhausted",
+			statusCode: http.StatusOK,
+			header: map[string]string{
+				headerRateLimit:     "60",
+				headerRateRemaining: "0",
+				headerRateReset:     strconv.FormatInt(now.Add(30*time.Minute).Unix(), 10),
+			},
+			expectedSecondary: false,
+			expectedWait:      30 * time.Minute,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			var err error
+
+			var logger micrologger.Logger
+			{
+				c := micrologger.Config{
+					IOWriter: ioutil.Discard,
+				}
+
+				logger, err = micrologger.New(c)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			var r *RateLimit
+			{
+				c := RateLimitConfig{
+					Logger: logger,
+
+					Threshold: 100,
+					Token:     "test",
+				}
+
+				r, err = NewRateLimit(c)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			header := http.Header{}
+			for k, v := range tc.header {
+				header.Set(k, v)
+			}
+
+			res := &http.Response{
+				Header:     header,
+				StatusCode: tc.statusCode,
+			}
+
+			secondary := r.update(res, now)
+			if secondary != tc.expectedSecondary {
+				t.Fatalf("\n\n%s\n", cmp.Diff(secondary, tc.expectedSecondary))
+			}
+
+			wait := r.wait(now)
+			if wait != tc.expectedWait {
+				t.Fatalf("\n\n%s\n", cmp.Diff(wait.String(), tc.expectedWait.String()))
+			}
+		})
+	}
+}
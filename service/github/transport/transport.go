@@ -0,0 +1,14 @@
+// Package transport provides HTTP transport layers for the Github API client.
+// Transports which expose Prometheus metrics implement the exporterkit
+// collector interface, so that they can be added to the exporter's collector
+// set.
+package transport
+
+const (
+	namespace = "github_exporter"
+	subsystem = "api"
+)
+
+const (
+	labelToken = "token"
+)
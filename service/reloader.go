@@ -0,0 +1,325 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/github-exporter/service/configfile"
+)
+
+const (
+	// reloadDelay is the time to wait after the last change of the config file
+	// before reloading it. Editors and config map updates usually change files
+	// in multiple steps, which should only cause a single reload.
+	reloadDelay = 2 * time.Second
+)
+
+var (
+	reloadFailuresDesc *prometheus.Desc = prometheus.NewDesc(
+		prometheus.BuildFQName("github_exporter", "config", "reload_failures_total"),
+		"Number of failed reloads of the configuration.",
+		nil,
+		nil,
+	)
+)
+
+// collectorSet is the part of collector.Set used by the reloader.
+type collectorSet interface {
+	Boot(ctx context.Context)
+	Collect(ch chan<- prometheus.Metric)
+}
+
+type reloaderConfig struct {
+	// CollectorSet is the collector set created from the initial settings.
+	CollectorSet collectorSet
+	FlagSet      *pflag.FlagSet
+	Logger       micrologger.Logger
+	// NewCollectorSet creates a collector set from the current settings of
+	// Viper.
+	NewCollectorSet func() (collectorSet, error)
+	Viper           *viper.Viper
+
+	// ConfigPath is the path of the config file which is watched for changes,
+	// if any.
+	ConfigPath string
+	// Settings are the initially applied settings of the config file.
+	Settings map[string]interface{}
+}
+
+// reloader rebuilds the collector set whenever the config file changes or
+// SIGHUP is received. It is registered with Prometheus instead of the collector
+// sets and delegates collecting to the current one. The new collector set is
+// booted in the background and only replaces the current one once it was
+// refreshed, so that reloads neither block watching for further changes nor
+// cause gaps in the metrics. The current collector set stops refreshing while
+// the new one boots and keeps exporting its latest snapshot. Failed reloads
+// keep the current collector set and settings.
+type reloader struct {
+	flagSet         *pflag.FlagSet
+	logger          micrologger.Logger
+	newCollectorSet func() (collectorSet, error)
+	viper           *viper.Viper
+
+	mutex        sync.RWMutex
+	boots        sync.WaitGroup
+	cancel       context.CancelFunc
+	collectorSet collectorSet
+	failures     float64
+	// pendingCancel stops the collector set which is currently booting, if
+	// any. It is only used by the watch loop.
+	pendingCancel context.CancelFunc
+	// settings are the settings currently applied to viper. They belong to
+	// the pending collector set while one is booting.
+	settings map[string]interface{}
+
+	configPath string
+}
+
+func newReloader(config reloaderConfig) (*reloader, error) {
+	if config.CollectorSet == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.CollectorSet must not be empty", config)
+	}
+	if config.FlagSet == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FlagSet must not be empty", config)
+	}
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+	if config.NewCollectorSet == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.NewCollectorSet must not be empty", config)
+	}
+	if config.Viper == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Viper must not be empty", config)
+	}
+
+	r := &reloader{
+		flagSet:         config.FlagSet,
+		logger:          config.Logger,
+		newCollectorSet: config.NewCollectorSet,
+		viper:           config.Viper,
+
+		mutex:         sync.RWMutex{},
+		boots:         sync.WaitGroup{},
+		cancel:        func() {},
+		collectorSet:  config.CollectorSet,
+		failures:      0,
+		pendingCancel: func() {},
+		settings:      config.Settings,
+
+		configPath: config.ConfigPath,
+	}
+
+	return r, nil
+}
+
+// Boot registers the reloader with Prometheus, boots the initial collector set
+// in the background and watches for configuration changes until the given
+// context is done.
+func (r *reloader) Boot(ctx context.Context) {
+	err := prometheus.Register(r)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", "failed registering collector", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+	}
+
+	{
+		setCtx, cancel := context.WithCancel(ctx)
+
+		r.mutex.Lock()
+		r.cancel = cancel
+		collectorSet := r.collectorSet
+		r.mutex.Unlock()
+
+		go collectorSet.Boot(setCtx)
+	}
+
+	r.watch(ctx)
+}
+
+func (r *reloader) Collect(ch chan<- prometheus.Metric) {
+	r.mutex.RLock()
+	collectorSet := r.collectorSet
+	failures := r.failures
+	r.mutex.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(
+		reloadFailuresDesc,
+		prometheus.CounterValue,
+		failures,
+	)
+
+	collectorSet.Collect(ch)
+}
+
+// Describe does not describe any metrics, which makes the reloader an
+// unchecked collector. The metrics of reloaded collector sets may differ, e.g.
+// in the labels of the issue count, so they cannot be described upfront.
+func (r *reloader) Describe(ch chan<- *prometheus.Desc) {
+}
+
+func (r *reloader) watch(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	// The directory of the config file is watched instead of the file itself,
+	// because many tools replace files instead of writing them, e.g. Kubernetes
+	// when updating mounted config maps.
+	var events chan fsnotify.Event
+	var watchErrors chan error
+	if r.configPath != "" {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			r.logger.LogCtx(ctx, "level", "error", "message", "failed watching config file", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		} else {
+			defer watcher.Close()
+
+			err = watcher.Add(filepath.Dir(r.configPath))
+			if err != nil {
+				r.logger.LogCtx(ctx, "level", "error", "message", fmt.Sprintf("failed watching config file %#q", r.configPath), "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+			}
+
+			events = watcher.Events
+			watchErrors = watcher.Errors
+		}
+	}
+
+	var delay <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-events:
+			delay = time.After(reloadDelay)
+		case err := <-watchErrors:
+			r.logger.LogCtx(ctx, "level", "warning", "message", "failed watching config file", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+		case <-delay:
+			delay = nil
+			r.reload(ctx, false)
+		case <-signals:
+			r.logger.LogCtx(ctx, "level", "debug", "message", "received SIGHUP")
+			r.reload(ctx, true)
+		}
+	}
+}
+
+// reload rebuilds the collector set from the current configuration. Unless
+// forced, the collector set is only rebuilt if the settings of the config file
+// changed.
+func (r *reloader) reload(ctx context.Context, force bool) {
+	err := r.reloadCollectorSet(ctx, force)
+	if err != nil {
+		r.mutex.Lock()
+		r.failures++
+		r.mutex.Unlock()
+
+		r.logger.LogCtx(ctx, "level", "error", "message", "failed reloading configuration, keeping previous configuration", "stack", fmt.Sprintf("%#v", err))
+		return
+	}
+}
+
+func (r *reloader) reloadCollectorSet(ctx context.Context, force bool) error {
+	settings := r.settings
+	if r.configPath != "" {
+		var err error
+		settings, err = configfile.Read(r.flagSet, r.configPath)
+		if err != nil {
+			return microerror.Mask(err)
+		}
+	}
+
+	if !force && reflect.DeepEqual(settings, r.settings) {
+		return nil
+	}
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "reloading configuration")
+
+	// The previous settings are applied again if the new ones do not produce a
+	// working collector set, so that viper always reflects the configuration
+	// in use.
+	collectorSet, err := r.applySettings(settings)
+	if err != nil {
+		r.restoreSettings(ctx)
+		return microerror.Mask(err)
+	}
+
+	// A collector set which is still booting was created from outdated
+	// settings, so it is dropped. The current collector set stops refreshing,
+	// so that it does not compete with the new one for API quota and the
+	// store.
+	r.pendingCancel()
+	r.mutex.RLock()
+	r.cancel()
+	r.mutex.RUnlock()
+
+	setCtx, cancel := context.WithCancel(ctx)
+	r.pendingCancel = cancel
+	r.settings = settings
+
+	r.boots.Add(1)
+	go func() {
+		defer r.boots.Done()
+		r.boot(setCtx, cancel, collectorSet)
+	}()
+
+	return nil
+}
+
+// boot boots the given collector set and replaces the current one with it,
+// unless the given context was canceled in the meantime.
+func (r *reloader) boot(ctx context.Context, cancel context.CancelFunc, collectorSet collectorSet) {
+	collectorSet.Boot(ctx)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	r.cancel = cancel
+	r.collectorSet = collectorSet
+
+	r.logger.LogCtx(ctx, "level", "debug", "message", "reloaded configuration")
+}
+
+func (r *reloader) applySettings(settings map[string]interface{}) (collectorSet, error) {
+	if r.configPath != "" {
+		err := configfile.Apply(r.viper, settings)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	collectorSet, err := r.newCollectorSet()
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	return collectorSet, nil
+}
+
+// restoreSettings applies the settings of the current collector set again.
+func (r *reloader) restoreSettings(ctx context.Context) {
+	if r.configPath == "" {
+		return
+	}
+
+	err := configfile.Apply(r.viper, r.settings)
+	if err != nil {
+		r.logger.LogCtx(ctx, "level", "error", "message", "failed restoring previous configuration", "stack", fmt.Sprintf("%#v", microerror.Mask(err)))
+	}
+}
@@ -0,0 +1,252 @@
+package service
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+
+	"github.com/giantswarm/github-exporter/service/configfile"
+)
+
+type fakeCollectorSet struct {
+	// release blocks Boot until it is closed, if given.
+	release chan struct{}
+	ctx     context.Context
+}
+
+func (s *fakeCollectorSet) Boot(ctx context.Context) {
+	s.ctx = ctx
+	if s.release != nil {
+		<-s.release
+	}
+}
+
+func (s *fakeCollectorSet) Collect(ch chan<- prometheus.Metric) {
+}
+
+func Test_Service_reloader_reload(t *testing.T) {
+	testCases := []struct {
+		name             string
+		initialDocument  string
+		document         string
+		force            bool
+		newError         error
+		expectedBuilt    bool
+		expectedFailures float64
+		expectedReplaced bool
+		expectedInterval time.Duration
+	}{
+		{
+			name:             "case 0 changed settings replace the collector set",
+			initialDocument:  "service: {collector: {interval: 10m}}",
+			document:         "service: {collector: {interval: 20m}}",
+			expectedBuilt:    true,
+			expectedFailures: 0,
+			expectedReplaced: true,
+			expectedInterval: 20 * time.Minute,
+		},
+		{
+			name:             "case 1 unchanged settings do not rebuild the collector set",
+			initialDocument:  "service: {collector: {interval: 10m}}",
+			document:         "service: {collector: {interval: 10m}}",
+			expectedBuilt:    false,
+			expectedFailures: 0,
+			expectedReplaced: false,
+			expectedInterval: 10 * time.Minute,
+		},
+		{
+			name:             "case 2 forced reload rebuilds the collector set with unchanged settings",
+			initialDocument:  "service: {collector: {interval: 10m}}",
+			document:         "service: {collector: {interval: 10m}}",
+			force:            true,
+			expectedBuilt:    true,
+			expectedFailures: 0,
+			expectedReplaced: true,
+			expectedInterval: 10 * time.Minute,
+		},
+		{
+			name:             "case 3 failing to create the collector set keeps the previous one",
+			initialDocument:  "service: {collector: {interval: 10m}}",
+			document:         "service: {collector: {interval: 20m}}",
+			newError:         microerror.Mask(invalidConfigError),
+			expectedBuilt:    true,
+			expectedFailures: 1,
+			expectedReplaced: false,
+			expectedInterval: 10 * time.Minute,
+		},
+		{
+			name:             "case 4 invalid config file keeps the previous collector set",
+			initialDocument:  "service: {collector: {interval: 10m}}",
+			document:         "service: {collector: {unknown: true}}",
+			expectedBuilt:    false,
+			expectedFailures: 1,
+			expectedReplaced: false,
+			expectedInterval: 10 * time.Minute,
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "reloader")
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			defer os.RemoveAll(dir)
+
+			configPath := filepath.Join(dir, "config.yaml")
+			err = ioutil.WriteFile(configPath, []byte(tc.document), 0600)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+			fs.Duration("service.collector.interval", 5*time.Minute, "")
+
+			v := viper.New()
+			err = v.BindPFlags(fs)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			settings, err := configfile.Parse(fs, []byte(tc.initialDocument))
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			err = configfile.Apply(v, settings)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			r, built := newTestReloader(t, fs, v, configPath, settings, tc.newError, nil)
+			previous := r.collectorSet
+
+			r.reload(context.Background(), tc.force)
+			r.boots.Wait()
+
+			if *built != tc.expectedBuilt {
+				t.Fatalf("\n\n%s\n", cmp.Diff(*built, tc.expectedBuilt))
+			}
+			if r.failures != tc.expectedFailures {
+				t.Fatalf("\n\n%s\n", cmp.Diff(r.failures, tc.expectedFailures))
+			}
+			replaced := r.collectorSet != previous
+			if replaced != tc.expectedReplaced {
+				t.Fatalf("\n\n%s\n", cmp.Diff(replaced, tc.expectedReplaced))
+			}
+			interval := v.GetDuration("service.collector.interval")
+			if interval != tc.expectedInterval {
+				t.Fatalf("\n\n%s\n", cmp.Diff(interval.String(), tc.expectedInterval.String()))
+			}
+			if !tc.expectedReplaced && !cmp.Equal(r.settings, settings) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(r.settings, settings))
+			}
+		})
+	}
+}
+
+func Test_Service_reloader_reload_cancel(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	v := viper.New()
+
+	r, _ := newTestReloader(t, fs, v, "", map[string]interface{}{}, nil, nil)
+
+	r.reload(context.Background(), true)
+	r.boots.Wait()
+	first := r.collectorSet.(*fakeCollectorSet)
+	if first.ctx.Err() != nil {
+		t.Fatalf("expected %#v got %#v", nil, first.ctx.Err())
+	}
+
+	r.reload(context.Background(), true)
+	r.boots.Wait()
+	second := r.collectorSet.(*fakeCollectorSet)
+	if first.ctx.Err() != context.Canceled {
+		t.Fatalf("expected %#v got %#v", context.Canceled, first.ctx.Err())
+	}
+	if second.ctx.Err() != nil {
+		t.Fatalf("expected %#v got %#v", nil, second.ctx.Err())
+	}
+}
+
+func Test_Service_reloader_reload_pending(t *testing.T) {
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	v := viper.New()
+
+	release := make(chan struct{})
+	r, _ := newTestReloader(t, fs, v, "", map[string]interface{}{}, nil, release)
+	initial := r.collectorSet
+
+	// The reload must not wait for the new collector set to be booted.
+	r.reload(context.Background(), true)
+	r.mutex.RLock()
+	current := r.collectorSet
+	r.mutex.RUnlock()
+	if current != initial {
+		t.Fatalf("expected the initial collector set to be kept while the new one boots")
+	}
+
+	// A further reload drops the collector set which is still booting.
+	r.reload(context.Background(), true)
+	close(release)
+	r.boots.Wait()
+
+	if r.collectorSet == initial {
+		t.Fatalf("expected the initial collector set to be replaced")
+	}
+	last := r.collectorSet.(*fakeCollectorSet)
+	if last.ctx.Err() != nil {
+		t.Fatalf("expected %#v got %#v", nil, last.ctx.Err())
+	}
+	if r.failures != 0 {
+		t.Fatalf("\n\n%s\n", cmp.Diff(r.failures, float64(0)))
+	}
+}
+
+// newTestReloader returns a reloader whose collector sets are fakes. Creating
+// them fails with the given error, if any, and booting them blocks until the
+// given channel is closed, if any. The returned flag reports whether a
+// collector set was created.
+func newTestReloader(t *testing.T, fs *pflag.FlagSet, v *viper.Viper, configPath string, settings map[string]interface{}, newError error, release chan struct{}) (*reloader, *bool) {
+	logger, err := micrologger.New(micrologger.Config{IOWriter: ioutil.Discard})
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	built := false
+	newCollectorSet := func() (collectorSet, error) {
+		built = true
+		if newError != nil {
+			return nil, newError
+		}
+		return &fakeCollectorSet{release: release}, nil
+	}
+
+	c := reloaderConfig{
+		CollectorSet:    &fakeCollectorSet{},
+		FlagSet:         fs,
+		Logger:          logger,
+		NewCollectorSet: newCollectorSet,
+		Viper:           v,
+
+		ConfigPath: configPath,
+		Settings:   settings,
+	}
+
+	r, err := newReloader(c)
+	if err != nil {
+		t.Fatalf("expected %#v got %#v", nil, err)
+	}
+
+	return r, &built
+}
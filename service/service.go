@@ -2,15 +2,31 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
 	"sync"
+	"time"
 
+	exporterkitcollector "github.com/giantswarm/exporterkit/collector"
 	"github.com/giantswarm/github-exporter/flag"
 	"github.com/giantswarm/github-exporter/service/collector"
+	"github.com/giantswarm/github-exporter/service/configfile"
+	"github.com/giantswarm/github-exporter/service/github/auth"
+	"github.com/giantswarm/github-exporter/service/github/transport"
+	"github.com/giantswarm/github-exporter/service/store"
+	"github.com/giantswarm/github-exporter/service/store/file"
+	"github.com/giantswarm/github-exporter/service/store/memory"
 	"github.com/giantswarm/microendpoint/service/version"
 	"github.com/giantswarm/microerror"
+	microtls "github.com/giantswarm/microkit/tls"
 	"github.com/giantswarm/micrologger"
 	"github.com/google/go-github/github"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 	"golang.org/x/oauth2"
 )
@@ -20,6 +36,9 @@ type Config struct {
 
 	Description string
 	Flag        *flag.Flag
+	// FlagSet holds the flags the settings of the exporter are given by. It is
+	// used to validate config files.
+	FlagSet     *pflag.FlagSet
 	GitCommit   string
 	ProjectName string
 	Source      string
@@ -29,8 +48,8 @@ type Config struct {
 type Service struct {
 	Version *version.Service
 
-	bootOnce          sync.Once
-	exporterCollector *collector.Set
+	bootOnce sync.Once
+	reloader *reloader
 }
 
 func New(config Config) (*Service, error) {
@@ -43,34 +62,250 @@ func New(config Config) (*Service, error) {
 	if config.Flag == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Flag must not be empty", config)
 	}
+	if config.FlagSet == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.FlagSet must not be empty", config)
+	}
 	if config.Viper == nil {
 		return nil, microerror.Maskf(invalidConfigError, "%T.Viper must not be empty", config)
 	}
 
 	var err error
 
+	// The config file, if any, has to be loaded before any setting is read.
+	configPath := config.Viper.GetString(config.Flag.Config)
+	var settings map[string]interface{}
+	if configPath != "" {
+		settings, err = configfile.Read(config.FlagSet, configPath)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+
+		err = configfile.Apply(config.Viper, settings)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// httpClient is the underlying HTTP client of all requests against the
+	// Github API, including the ones for authentication.
+	var httpClient *http.Client
+	{
+		c := microtls.CertFiles{
+			Cert: config.Viper.GetString(config.Flag.Service.Github.TLS.CrtFile),
+			Key:  config.Viper.GetString(config.Flag.Service.Github.TLS.KeyFile),
+		}
+		if config.Viper.GetString(config.Flag.Service.Github.TLS.CaFile) != "" {
+			c.RootCAs = []string{config.Viper.GetString(config.Flag.Service.Github.TLS.CaFile)}
+		}
+
+		tlsConfig, err := microtls.LoadTLSConfig(c)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "loading TLS configuration failed: %s", err)
+		}
+
+		if config.Viper.GetBool(config.Flag.Service.Github.TLS.InsecureSkipVerify) {
+			if tlsConfig == nil {
+				tlsConfig = &tls.Config{}
+			}
+			tlsConfig.InsecureSkipVerify = true
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+
+		httpClient = &http.Client{
+			Transport: transport,
+		}
+	}
+
+	var baseURL string
+	var uploadURL string
+	{
+		baseURL = config.Viper.GetString(config.Flag.Service.Github.BaseURL)
+		uploadURL = config.Viper.GetString(config.Flag.Service.Github.UploadURL)
+
+		if baseURL == "" && uploadURL != "" {
+			return nil, microerror.Maskf(invalidConfigError, "%s must not be empty when %s is given", config.Flag.Service.Github.BaseURL, config.Flag.Service.Github.UploadURL)
+		}
+		if uploadURL == "" {
+			uploadURL = baseURL
+		}
+	}
+
+	// tokenName identifies the credentials used to access the Github API in
+	// metrics without revealing any secret.
+	var tokenName string
+	var tokenSource oauth2.TokenSource
+	{
+		appID := config.Viper.GetInt64(config.Flag.Service.Github.Auth.App.ID)
+		token := config.Viper.GetString(config.Flag.Service.Github.Auth.Token)
+
+		if appID != 0 && token != "" {
+			return nil, microerror.Maskf(invalidConfigError, "%s and %s must not be given both", config.Flag.Service.Github.Auth.App.ID, config.Flag.Service.Github.Auth.Token)
+		}
+
+		if appID != 0 {
+			privateKey, err := ioutil.ReadFile(config.Viper.GetString(config.Flag.Service.Github.Auth.App.PrivateKeyFile))
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "%s must be a readable file: %s", config.Flag.Service.Github.Auth.App.PrivateKeyFile, err)
+			}
+
+			c := auth.AppConfig{
+				HTTPClient: httpClient,
+
+				AppID:          appID,
+				BaseURL:        baseURL,
+				InstallationID: config.Viper.GetInt64(config.Flag.Service.Github.Auth.App.InstallationID),
+				PrivateKey:     privateKey,
+			}
+
+			app, err := auth.NewApp(c)
+			if err != nil {
+				return nil, microerror.Mask(withConfigKeys(config.Flag, err))
+			}
+
+			tokenName = fmt.Sprintf("app/%d/%d", c.AppID, c.InstallationID)
+			tokenSource = oauth2.ReuseTokenSource(nil, app)
+		} else if token != "" {
+			sum := sha256.Sum256([]byte(token))
+
+			tokenName = fmt.Sprintf("token/%x", sum[:4])
+			tokenSource = oauth2.StaticTokenSource(
+				&oauth2.Token{
+					AccessToken: token,
+				},
+			)
+		} else {
+			tokenName = "anonymous"
+			tokenSource = oauth2.StaticTokenSource(&oauth2.Token{})
+		}
+	}
+
+	var instrumentationTransport *transport.Instrumentation
+	{
+		c := transport.InstrumentationConfig{
+			Transport: httpClient.Transport,
+		}
+
+		instrumentationTransport, err = transport.NewInstrumentation(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var rateLimitTransport *transport.RateLimit
+	{
+		c := transport.RateLimitConfig{
+			Logger:    config.Logger,
+			Transport: instrumentationTransport,
+
+			Threshold: config.Viper.GetInt(config.Flag.Service.Github.RateLimit.Threshold),
+			Token:     tokenName,
+		}
+
+		rateLimitTransport, err = transport.NewRateLimit(c)
+		if err != nil {
+			return nil, microerror.Mask(withConfigKeys(config.Flag, err))
+		}
+	}
+
+	// The cache wraps the other transports, so that conditional requests
+	// answered with 304 Not Modified are still tracked by the rate limit and
+	// request metrics. It is disabled if the maximum size in bytes is 0.
+	apiCollectors := []exporterkitcollector.Interface{
+		instrumentationTransport,
+		rateLimitTransport,
+	}
+	var apiTransport http.RoundTripper = rateLimitTransport
+	if config.Viper.GetInt(config.Flag.Service.Github.Cache.MaxBytes) > 0 {
+		c := transport.CacheConfig{
+			Transport: rateLimitTransport,
+
+			MaxBytes: config.Viper.GetInt(config.Flag.Service.Github.Cache.MaxBytes),
+		}
+
+		cacheTransport, err := transport.NewCache(c)
+		if err != nil {
+			return nil, microerror.Mask(withConfigKeys(config.Flag, err))
+		}
+
+		apiCollectors = append(apiCollectors, cacheTransport)
+		apiTransport = cacheTransport
+	}
+
 	var githubClient *github.Client
 	{
-		ctx := context.Background()
-		ts := oauth2.StaticTokenSource(
-			&oauth2.Token{
-				AccessToken: config.Viper.GetString(config.Flag.Service.Github.Auth.Token),
-			},
-		)
+		apiClient := &http.Client{
+			Transport: apiTransport,
+		}
 
-		githubClient = github.NewClient(oauth2.NewClient(ctx, ts))
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, apiClient)
+		oauthClient := oauth2.NewClient(ctx, tokenSource)
+
+		if baseURL == "" {
+			githubClient = github.NewClient(oauthClient)
+		} else {
+			// Github Enterprise Server serves its API under a custom base URL,
+			// e.g. https://github.example.com/api/v3/.
+			githubClient, err = github.NewEnterpriseClient(baseURL, uploadURL, oauthClient)
+			if err != nil {
+				return nil, microerror.Maskf(invalidConfigError, "%s and %s must be valid URLs: %s", config.Flag.Service.Github.BaseURL, config.Flag.Service.Github.UploadURL, err)
+			}
+		}
 	}
 
-	var exporterCollector *collector.Set
+	// The state of the collectors is only persisted when a directory is
+	// configured. Otherwise it is kept in memory and lost on restart.
+	var stateStore store.Interface
+	if config.Viper.GetString(config.Flag.Service.Store.Directory) != "" {
+		c := file.Config{
+			Logger: config.Logger,
+
+			Directory: config.Viper.GetString(config.Flag.Service.Store.Directory),
+		}
+
+		stateStore, err = file.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	} else {
+		c := memory.Config{}
+
+		stateStore, err = memory.New(c)
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	// buildCollectorSet creates the collector set from the current settings. It
+	// is called again whenever the configuration is reloaded, while the
+	// settings of the Github client and the store are only read on startup.
+	buildCollectorSet := func() (collectorSet, error) {
+		return newCollectorSet(config, githubClient, apiCollectors, stateStore)
+	}
+
+	var exporterCollector collectorSet
 	{
-		c := collector.SetConfig{
-			GithubClient: githubClient,
-			Logger:       config.Logger,
+		exporterCollector, err = buildCollectorSet()
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
 
-			CustomLabels: mustParseJSONList(config.Viper.GetString(config.Flag.Service.Collector.Issue.CustomLabels)),
+	var collectorReloader *reloader
+	{
+		c := reloaderConfig{
+			CollectorSet:    exporterCollector,
+			FlagSet:         config.FlagSet,
+			Logger:          config.Logger,
+			NewCollectorSet: buildCollectorSet,
+			Viper:           config.Viper,
+
+			ConfigPath: configPath,
+			Settings:   settings,
 		}
 
-		exporterCollector, err = collector.NewSet(c)
+		collectorReloader, err = newReloader(c)
 		if err != nil {
 			return nil, microerror.Mask(err)
 		}
@@ -94,8 +329,8 @@ func New(config Config) (*Service, error) {
 	s := &Service{
 		Version: versionService,
 
-		bootOnce:          sync.Once{},
-		exporterCollector: exporterCollector,
+		bootOnce: sync.Once{},
+		reloader: collectorReloader,
 	}
 
 	return s, nil
@@ -103,16 +338,236 @@ func New(config Config) (*Service, error) {
 
 func (s *Service) Boot(ctx context.Context) {
 	s.bootOnce.Do(func() {
-		go s.exporterCollector.Boot(ctx)
+		go s.reloader.Boot(ctx)
 	})
 }
 
-func mustParseJSONList(s string) []string {
+// newCollectorSet creates the collector set from the collector settings given
+// by the viper of the given config.
+func newCollectorSet(config Config, githubClient *github.Client, apiCollectors []exporterkitcollector.Interface, stateStore store.Interface) (*collector.Set, error) {
+	var err error
+
+	// lists holds the settings given as JSON lists, keyed by their flags.
+	lists := map[string][]string{}
+	{
+		keys := []string{
+			config.Flag.Service.Collector.Discovery.Exclude,
+			config.Flag.Service.Collector.Discovery.Include,
+			config.Flag.Service.Collector.Discovery.Organizations,
+			config.Flag.Service.Collector.Issue.Breakdown.Selectors,
+			config.Flag.Service.Collector.Issue.Breakdown.Teams,
+			config.Flag.Service.Collector.Issue.Breakdown.Users,
+			config.Flag.Service.Collector.Issue.CustomLabels,
+			config.Flag.Service.Collector.Issue.Dimensions,
+			config.Flag.Service.Collector.Issue.LifetimeBuckets,
+			config.Flag.Service.Collector.Issue.StaleThresholds,
+			config.Flag.Service.Collector.PullRequest.Buckets,
+			config.Flag.Service.Collector.Repositories,
+			config.Flag.Service.Collector.Workflow.Buckets,
+		}
+
+		for _, k := range keys {
+			l, err := parseJSONList(k, config.Viper.GetString(k))
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			lists[k] = l
+		}
+	}
+
+	var repositories []collector.Repository
+	{
+		for _, s := range lists[config.Flag.Service.Collector.Repositories] {
+			r, err := collector.NewRepository(s)
+			if err != nil {
+				return nil, microerror.Mask(err)
+			}
+
+			repositories = append(repositories, r)
+		}
+	}
+
+	var lifetimeBuckets []float64
+	{
+		lifetimeBuckets, err = parseBuckets(config.Flag.Service.Collector.Issue.LifetimeBuckets, lists[config.Flag.Service.Collector.Issue.LifetimeBuckets])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var pullRequestBuckets []float64
+	{
+		pullRequestBuckets, err = parseBuckets(config.Flag.Service.Collector.PullRequest.Buckets, lists[config.Flag.Service.Collector.PullRequest.Buckets])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var workflowBuckets []float64
+	{
+		workflowBuckets, err = parseBuckets(config.Flag.Service.Collector.Workflow.Buckets, lists[config.Flag.Service.Collector.Workflow.Buckets])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var staleThresholds []time.Duration
+	{
+		staleThresholds, err = parseDurations(config.Flag.Service.Collector.Issue.StaleThresholds, lists[config.Flag.Service.Collector.Issue.StaleThresholds])
+		if err != nil {
+			return nil, microerror.Mask(err)
+		}
+	}
+
+	var exporterCollector *collector.Set
+	{
+		c := collector.SetConfig{
+			Collectors:   apiCollectors,
+			GithubClient: githubClient,
+			Logger:       config.Logger,
+			Store:        stateStore,
+
+			BreakdownLimit:         config.Viper.GetInt(config.Flag.Service.Collector.Issue.Breakdown.Limit),
+			BreakdownSelectors:     lists[config.Flag.Service.Collector.Issue.Breakdown.Selectors],
+			BreakdownTeams:         lists[config.Flag.Service.Collector.Issue.Breakdown.Teams],
+			BreakdownUsers:         lists[config.Flag.Service.Collector.Issue.Breakdown.Users],
+			CheckEnabled:           config.Viper.GetBool(config.Flag.Service.Collector.Check.Enabled),
+			CustomLabels:           lists[config.Flag.Service.Collector.Issue.CustomLabels],
+			Dimensions:             lists[config.Flag.Service.Collector.Issue.Dimensions],
+			DiscoveryExclude:       lists[config.Flag.Service.Collector.Discovery.Exclude],
+			DiscoveryInclude:       lists[config.Flag.Service.Collector.Discovery.Include],
+			DiscoveryInterval:      config.Viper.GetDuration(config.Flag.Service.Collector.Discovery.Interval),
+			DiscoveryOrganizations: lists[config.Flag.Service.Collector.Discovery.Organizations],
+			DiscoverySkipArchived:  config.Viper.GetBool(config.Flag.Service.Collector.Discovery.SkipArchived),
+			DiscoverySkipForks:     config.Viper.GetBool(config.Flag.Service.Collector.Discovery.SkipForks),
+			DiscoverySkipPrivate:   config.Viper.GetBool(config.Flag.Service.Collector.Discovery.SkipPrivate),
+			FirstResponseBudget:    config.Viper.GetInt(config.Flag.Service.Collector.Issue.FirstResponse.Budget),
+			FirstResponseEnabled:   config.Viper.GetBool(config.Flag.Service.Collector.Issue.FirstResponse.Enabled),
+			FirstResponseWindow:    config.Viper.GetDuration(config.Flag.Service.Collector.Issue.FirstResponse.Window),
+			Interval:               config.Viper.GetDuration(config.Flag.Service.Collector.Interval),
+			LifetimeBuckets:        lifetimeBuckets,
+			MilestoneEnabled:       config.Viper.GetBool(config.Flag.Service.Collector.Milestone.Enabled),
+			MilestoneState:         config.Viper.GetString(config.Flag.Service.Collector.Milestone.State),
+			PullRequestBuckets:     pullRequestBuckets,
+			PullRequestEnabled:     config.Viper.GetBool(config.Flag.Service.Collector.PullRequest.Enabled),
+			PullRequestRetention:   config.Viper.GetDuration(config.Flag.Service.Collector.PullRequest.Retention),
+			ReleaseAssetPattern:    config.Viper.GetString(config.Flag.Service.Collector.Release.AssetPattern),
+			ReleaseEnabled:         config.Viper.GetBool(config.Flag.Service.Collector.Release.Enabled),
+			ReleaseRecentReleases:  config.Viper.GetInt(config.Flag.Service.Collector.Release.RecentReleases),
+			Repositories:           repositories,
+			RepositoryEnabled:      config.Viper.GetBool(config.Flag.Service.Collector.Repository.Enabled),
+			Retention:              config.Viper.GetDuration(config.Flag.Service.Collector.Issue.Retention),
+			StaleThresholds:        staleThresholds,
+			TrafficEnabled:         config.Viper.GetBool(config.Flag.Service.Collector.Traffic.Enabled),
+			TrafficTopN:            config.Viper.GetInt(config.Flag.Service.Collector.Traffic.TopN),
+			WorkflowBuckets:        workflowBuckets,
+			WorkflowEnabled:        config.Viper.GetBool(config.Flag.Service.Collector.Workflow.Enabled),
+			WorkflowRetention:      config.Viper.GetDuration(config.Flag.Service.Collector.Workflow.Retention),
+		}
+
+		exporterCollector, err = collector.NewSet(c)
+		if err != nil {
+			return nil, microerror.Mask(withConfigKeys(config.Flag, err))
+		}
+	}
+
+	return exporterCollector, nil
+}
+
+// withConfigKeys replaces the fields of config structs mentioned in the message
+// of the given error by the keys of the settings they are given by, e.g.
+// collector.IssueConfig.CustomLabels[0] by
+// service.collector.issue.customlabels[0]. Validation errors of the collectors
+// and the Github client are this way reported in terms of the configuration.
+func withConfigKeys(f *flag.Flag, err error) error {
+	keys := map[string]string{
+		"auth.AppConfig.AppID":                      f.Service.Github.Auth.App.ID,
+		"auth.AppConfig.InstallationID":             f.Service.Github.Auth.App.InstallationID,
+		"auth.AppConfig.PrivateKey":                 f.Service.Github.Auth.App.PrivateKeyFile,
+		"collector.DiscoveryConfig.Interval":        f.Service.Collector.Discovery.Interval,
+		"collector.DiscoveryConfig.Organizations":   f.Service.Collector.Discovery.Organizations,
+		"collector.DiscoveryConfig.Repositories":    f.Service.Collector.Repositories,
+		"collector.IssueConfig.BreakdownLimit":      f.Service.Collector.Issue.Breakdown.Limit,
+		"collector.IssueConfig.BreakdownSelectors":  f.Service.Collector.Issue.Breakdown.Selectors,
+		"collector.IssueConfig.BreakdownTeams":      f.Service.Collector.Issue.Breakdown.Teams,
+		"collector.IssueConfig.CustomLabels":        f.Service.Collector.Issue.CustomLabels,
+		"collector.IssueConfig.Dimensions":          f.Service.Collector.Issue.Dimensions,
+		"collector.IssueConfig.FirstResponseBudget": f.Service.Collector.Issue.FirstResponse.Budget,
+		"collector.IssueConfig.FirstResponseWindow": f.Service.Collector.Issue.FirstResponse.Window,
+		"collector.IssueConfig.LifetimeBuckets":     f.Service.Collector.Issue.LifetimeBuckets,
+		"collector.IssueConfig.Retention":           f.Service.Collector.Issue.Retention,
+		"collector.IssueConfig.StaleThresholds":     f.Service.Collector.Issue.StaleThresholds,
+		"collector.MilestoneConfig.State":           f.Service.Collector.Milestone.State,
+		"collector.PollerConfig.Interval":           f.Service.Collector.Interval,
+		"collector.PullRequestConfig.Buckets":       f.Service.Collector.PullRequest.Buckets,
+		"collector.PullRequestConfig.Retention":     f.Service.Collector.PullRequest.Retention,
+		"collector.ReleaseConfig.AssetPattern":      f.Service.Collector.Release.AssetPattern,
+		"collector.ReleaseConfig.RecentReleases":    f.Service.Collector.Release.RecentReleases,
+		"collector.TrafficConfig.TopN":              f.Service.Collector.Traffic.TopN,
+		"collector.WorkflowConfig.Buckets":          f.Service.Collector.Workflow.Buckets,
+		"collector.WorkflowConfig.Retention":        f.Service.Collector.Workflow.Retention,
+		"transport.CacheConfig.MaxBytes":            f.Service.Github.Cache.MaxBytes,
+		"transport.RateLimitConfig.Threshold":       f.Service.Github.RateLimit.Threshold,
+	}
+
+	// The message of the cause is appended to the message of the error, so it
+	// is stripped before wrapping the cause again.
+	cause := microerror.Cause(err)
+	original := strings.TrimSuffix(err.Error(), ": "+cause.Error())
+
+	message := original
+	for field, key := range keys {
+		message = strings.Replace(message, field, key, -1)
+	}
+	if message == original {
+		return err
+	}
+
+	return microerror.Maskf(cause, "%s", message)
+}
+
+// parseJSONList parses the given JSON list of strings. The given key is only
+// used for error messages.
+func parseJSONList(key string, s string) ([]string, error) {
 	var l []string
 	err := json.Unmarshal([]byte(s), &l)
 	if err != nil {
-		panic(err)
+		return nil, microerror.Maskf(invalidConfigError, "%s must be a JSON list of strings: %s", key, err)
+	}
+
+	return l, nil
+}
+
+// parseBuckets parses the given list of durations into histogram buckets in
+// seconds. The given key is only used for error messages.
+func parseBuckets(key string, l []string) ([]float64, error) {
+	durations, err := parseDurations(key, l)
+	if err != nil {
+		return nil, microerror.Mask(err)
+	}
+
+	var buckets []float64
+	for _, d := range durations {
+		buckets = append(buckets, d.Seconds())
+	}
+
+	return buckets, nil
+}
+
+// parseDurations parses the given list of durations. The given key is only
+// used for error messages.
+func parseDurations(key string, l []string) ([]time.Duration, error) {
+	var durations []time.Duration
+
+	for _, s := range l {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, microerror.Maskf(invalidConfigError, "%s must be a list of durations: %s", key, err)
+		}
+
+		durations = append(durations, d)
 	}
 
-	return l
+	return durations, nil
 }
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/microerror"
+
+	"github.com/giantswarm/github-exporter/flag"
+)
+
+func Test_Service_withConfigKeys(t *testing.T) {
+	testCases := []struct {
+		name            string
+		err             error
+		expectedMessage string
+	}{
+		{
+			name:            "case 0 fields of collector configs are replaced by setting keys",
+			err:             microerror.Maskf(microerror.Maskf(invalidConfigError, "selector `a AND`: unexpected end"), "collector.IssueConfig.CustomLabels[0]"),
+			expectedMessage: "service.collector.issue.customlabels[0]: selector `a AND`: unexpected end: invalid config error",
+		},
+		{
+			name:            "case 1 multiple fields are replaced",
+			err:             microerror.Maskf(invalidConfigError, "collector.DiscoveryConfig.Organizations or collector.DiscoveryConfig.Repositories must not be empty"),
+			expectedMessage: "service.collector.discovery.organizations or service.collector.repositories must not be empty: invalid config error",
+		},
+		{
+			name:            "case 2 errors without fields are kept",
+			err:             microerror.Maskf(invalidConfigError, "repository `x` must be of the form org/repo"),
+			expectedMessage: "repository `x` must be of the form org/repo: invalid config error",
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			err := withConfigKeys(flag.New(), tc.err)
+
+			if !IsInvalidConfig(err) {
+				t.Fatalf("error == %#v, want matching", err)
+			}
+			if err.Error() != tc.expectedMessage {
+				t.Fatalf("expected %#q got %#q", tc.expectedMessage, err.Error())
+			}
+		})
+	}
+}
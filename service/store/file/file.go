@@ -0,0 +1,175 @@
+// Package file implements a store which keeps each value in a JSON file within
+// a local directory.
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+	"github.com/giantswarm/micrologger"
+)
+
+const (
+	// version is written to the header of every file. Files of other versions
+	// are discarded. It has to be incremented when the file format changes in
+	// an incompatible way. Changes of the stored values are versioned by the
+	// collectors storing them, so that other values are kept.
+	version = 1
+)
+
+type Config struct {
+	Logger micrologger.Logger
+
+	// Directory is the directory the files are written to. It is created if it
+	// does not exist.
+	Directory string
+}
+
+type Store struct {
+	logger micrologger.Logger
+
+	mutex sync.Mutex
+
+	directory string
+}
+
+// file is the content of a single file of the store.
+type file struct {
+	Version int             `json:"version"`
+	Key     string          `json:"key"`
+	Value   json.RawMessage `json:"value"`
+}
+
+func New(config Config) (*Store, error) {
+	if config.Logger == nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Logger must not be empty", config)
+	}
+
+	if config.Directory == "" {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Directory must not be empty", config)
+	}
+
+	err := os.MkdirAll(config.Directory, 0755)
+	if err != nil {
+		return nil, microerror.Maskf(invalidConfigError, "%T.Directory must be a writable directory: %s", config, err)
+	}
+
+	s := &Store{
+		logger: config.Logger,
+
+		mutex: sync.Mutex{},
+
+		directory: config.Directory,
+	}
+
+	return s, nil
+}
+
+// Get decodes the value stored under the given key into v, which must be a
+// non-nil pointer. Files which cannot be decoded or were written by an
+// incompatible version are removed and treated as if there was no value
+// stored. v is left untouched in this case.
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, microerror.Maskf(invalidConfigError, "v must be a non-nil pointer but got %T", v)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p := s.path(key)
+
+	b, err := ioutil.ReadFile(p)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	var f file
+	err = json.Unmarshal(b, &f)
+	if err == nil && f.Version != version {
+		err = fmt.Errorf("file version %d does not match version %d", f.Version, version)
+	}
+	if err == nil && f.Key != key {
+		err = fmt.Errorf("file key %#q does not match key %#q", f.Key, key)
+	}
+	// The value is decoded into a fresh value first, so that files which can
+	// only be decoded partially do not leave v half populated.
+	decoded := reflect.New(rv.Elem().Type())
+	if err == nil {
+		err = json.Unmarshal(f.Value, decoded.Interface())
+	}
+	if err != nil {
+		s.logger.Log("level", "warning", "message", fmt.Sprintf("discarding invalid file %#q", p), "stack", fmt.Sprintf("%#v", err))
+
+		err = os.Remove(p)
+		if err != nil {
+			return false, microerror.Mask(err)
+		}
+
+		return false, nil
+	}
+
+	rv.Elem().Set(decoded.Elem())
+
+	return true, nil
+}
+
+// Put stores v under the given key. The file is written atomically, so that
+// crashes do not leave partially written files behind.
+func (s *Store) Put(key string, v interface{}) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	value, err := json.Marshal(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	f := file{
+		Version: version,
+		Key:     key,
+		Value:   value,
+	}
+
+	b, err := json.Marshal(f)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	tmp, err := ioutil.TempFile(s.directory, ".tmp-")
+	if err != nil {
+		return microerror.Mask(err)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(b)
+	if err != nil {
+		tmp.Close()
+		return microerror.Mask(err)
+	}
+	err = tmp.Close()
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	err = os.Rename(tmp.Name(), s.path(key))
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	return nil
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.directory, url.PathEscape(key)+".json")
+}
@@ -0,0 +1,133 @@
+package file
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/giantswarm/micrologger"
+	"github.com/google/go-cmp/cmp"
+)
+
+func Test_Store_File_Get(t *testing.T) {
+	type value struct {
+		Name  string
+		Count int
+	}
+
+	testCases := []struct {
+		name          string
+		put           *value
+		content       string
+		expectedOK    bool
+		expectedValue value
+	}{
+		{
+			name:          "case 0 missing file",
+			expectedOK:    false,
+			expectedValue: value{},
+		},
+		{
+			name: "case 1 stored value is returned",
+			put: &value{
+				Name:  "issue",
+				Count: 23,
+			},
+			expectedOK: true,
+			expectedValue: value{
+				Name:  "issue",
+				Count: 23,
+			},
+		},
+		{
+			name:          "case 2 corrupt file is discarded",
+			content:       `{"version":1,"key":"test/key","value":{"Name":`,
+			expectedOK:    false,
+			expectedValue: value{},
+		},
+		{
+			name:          "case 3 file of other version is discarded",
+			content:       `{"version":0,"key":"test/key","value":{"Name":"issue","Count":23}}`,
+			expectedOK:    false,
+			expectedValue: value{},
+		},
+		{
+			name:          "case 4 partially decodable file is discarded without decoding any field",
+			content:       `{"version":1,"key":"test/key","value":{"Name":"issue","Count":"many"}}`,
+			expectedOK:    false,
+			expectedValue: value{},
+		},
+	}
+
+	for i, tc := range testCases {
+		t.Run(strconv.Itoa(i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "github-exporter-store")
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			defer os.RemoveAll(dir)
+
+			var logger micrologger.Logger
+			{
+				c := micrologger.Config{
+					IOWriter: ioutil.Discard,
+				}
+
+				logger, err = micrologger.New(c)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			var s *Store
+			{
+				c := Config{
+					Logger: logger,
+
+					Directory: dir,
+				}
+
+				s, err = New(c)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			if tc.put != nil {
+				err = s.Put("test/key", tc.put)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+			if tc.content != "" {
+				err = ioutil.WriteFile(s.path("test/key"), []byte(tc.content), 0644)
+				if err != nil {
+					t.Fatalf("expected %#v got %#v", nil, err)
+				}
+			}
+
+			var v value
+			ok, err := s.Get("test/key", &v)
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+
+			if ok != tc.expectedOK {
+				t.Fatalf("\n\n%s\n", cmp.Diff(ok, tc.expectedOK))
+			}
+			if !cmp.Equal(v, tc.expectedValue) {
+				t.Fatalf("\n\n%s\n", cmp.Diff(v, tc.expectedValue))
+			}
+
+			files, err := filepath.Glob(filepath.Join(dir, "*"))
+			if err != nil {
+				t.Fatalf("expected %#v got %#v", nil, err)
+			}
+			if !tc.expectedOK && len(files) != 0 {
+				t.Fatalf("expected invalid files to be removed got %v", files)
+			}
+		})
+	}
+}
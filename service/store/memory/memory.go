@@ -0,0 +1,57 @@
+// Package memory implements a store which keeps its values in process memory.
+// It is used when no persistent store is configured.
+package memory
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/giantswarm/microerror"
+)
+
+type Config struct {
+}
+
+type Store struct {
+	mutex  sync.RWMutex
+	values map[string][]byte
+}
+
+func New(config Config) (*Store, error) {
+	s := &Store{
+		mutex:  sync.RWMutex{},
+		values: map[string][]byte{},
+	}
+
+	return s, nil
+}
+
+func (s *Store) Get(key string, v interface{}) (bool, error) {
+	s.mutex.RLock()
+	b, ok := s.values[key]
+	s.mutex.RUnlock()
+
+	if !ok {
+		return false, nil
+	}
+
+	err := json.Unmarshal(b, v)
+	if err != nil {
+		return false, microerror.Mask(err)
+	}
+
+	return true, nil
+}
+
+func (s *Store) Put(key string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return microerror.Mask(err)
+	}
+
+	s.mutex.Lock()
+	s.values[key] = b
+	s.mutex.Unlock()
+
+	return nil
+}
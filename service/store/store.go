@@ -0,0 +1,16 @@
+// Package store defines how collectors persist their state, so that restarts
+// of the exporter do not cause all data to be fetched from the Github API
+// again.
+package store
+
+// Interface defines how a store implementation should look like. Values are
+// encoded as JSON, so any value which can be marshalled and unmarshalled using
+// encoding/json can be stored.
+type Interface interface {
+	// Get decodes the value stored under the given key into v. It returns false
+	// if there is no value stored under the given key.
+	Get(key string, v interface{}) (bool, error)
+	// Put stores v under the given key, replacing the value stored before, if
+	// any.
+	Put(key string, v interface{}) error
+}